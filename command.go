@@ -13,6 +13,12 @@ type Initializer func(*Command) error
 // initializer is called in order with the command to initialize passed as an argument.
 // If an error is returned from an initializer, then the command is not created
 // and the error is returned.
+//
+// Once all initializers have been called, any confirmation added with
+// WithConfirmation and then any middleware added with WithMiddleware is
+// applied to the run function of the command, the groups added with WithGroup
+// are added to the command in the order set with WithGroupOrder, and the
+// choices of each enum flag are registered as its shell completions.
 func NewCommand(initializers ...Initializer) (*Command, error) {
 	cmd := &Command{}
 	for _, initializer := range initializers {
@@ -20,6 +26,19 @@ func NewCommand(initializers ...Initializer) (*Command, error) {
 			return nil, err
 		}
 	}
+
+	if ext := lookupExtensions(cmd); ext != nil {
+		applyConfirmation(cmd, ext.confirmation)
+		applyMiddleware(cmd, ext.middleware)
+		if err := addGroups(cmd, ext.groups, ext.groupOrder); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := registerEnumCompletions(cmd); err != nil {
+		return nil, err
+	}
+
 	return cmd, nil
 }
 
@@ -68,6 +87,42 @@ func WithLong(long string) Initializer {
 	}
 }
 
+// WithPersistentFlag adds the specified flags to the command as persistent
+// flags, which are also available to every descendant of the command.
+func WithPersistentFlag(flags ...FlagInitializer) Initializer {
+	return func(cmd *Command) error {
+		for _, flag := range flags {
+			if err := flag(cmd.PersistentFlags()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// WithRequiredFlag marks the specified flags of the command as required. The
+// flags must be added to the command before the flags are marked as required.
+// If a flag does not exist, then an error is returned.
+func WithRequiredFlag(names ...string) Initializer {
+	return func(cmd *Command) error {
+		for _, name := range names {
+			var err error
+			if cmd.PersistentFlags().Lookup(name) != nil {
+				err = cmd.MarkPersistentFlagRequired(name)
+			} else {
+				err = cmd.MarkFlagRequired(name)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
 // WithRun sets the run function on the command.
 func WithRun(run func(*Command, []string)) Initializer {
 	return func(cmd *Command) error {
@@ -76,6 +76,43 @@ func TestWithLong(t *testing.T) {
 	assert.Equal(t, "foo", cmd.Long, "Long description should be set")
 }
 
+func TestWithPersistentFlag(t *testing.T) {
+	var value string
+	cmd, err := snek.NewCommand(snek.WithPersistentFlag(snek.WithStringVar(&value, "foo", "", "foo")))
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.NotNil(t, cmd.PersistentFlags().Lookup("foo"), "The flag should be added as a persistent flag")
+}
+
+func TestWithPersistentFlag_Error(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithPersistentFlag(func(flags *pflag.FlagSet) error {
+		return assert.AnError
+	}))
+	assert.ErrorIs(t, err, assert.AnError,
+		"NewCommand should return the error produced by the flag initializer")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+func TestWithRequiredFlag(t *testing.T) {
+	var local, persistent string
+	cmd, err := snek.NewCommand(
+		snek.WithFlag(snek.WithStringVar(&local, "local", "", "local")),
+		snek.WithPersistentFlag(snek.WithStringVar(&persistent, "persistent", "", "persistent")),
+		snek.WithRequiredFlag("local", "persistent"),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	cmd.SetArgs([]string{})
+	assert.ErrorContains(t, cmd.Execute(), `required flag(s) "local", "persistent" not set`,
+		"Execute should return an error if the required flags are not set")
+}
+
+func TestWithRequiredFlag_Error(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithRequiredFlag("missing"))
+	assert.Error(t, err, "NewCommand should return an error if the flag does not exist")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
 func TestWithRun(t *testing.T) {
 	cmd, err := snek.NewCommand(snek.WithRun(func(*cobra.Command, []string) {}))
 	require.NoError(t, err, "NewCommand should not return an error")
@@ -0,0 +1,203 @@
+package snek
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// UpdateCompatibleEnvironmentVariable is the environment variable that, when
+// set to a non-empty value, makes AssertCompatible save the current
+// description of the command instead of comparing it to the saved one.
+const UpdateCompatibleEnvironmentVariable = "SNEK_UPDATE_GOLDEN"
+
+// TestingT is the subset of testing.TB used by AssertCompatible.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertCompatible fails the test if the command tree is not backwards
+// compatible with the description saved as JSON at the specified path. See
+// CheckCompatible for the changes that are considered incompatible.
+//
+// If the UpdateCompatibleEnvironmentVariable environment variable is set, then
+// the current description is saved to the path instead, which is used to create
+// the saved description and to accept intentional changes.
+func AssertCompatible(t TestingT, cmd *Command, path string) bool {
+	t.Helper()
+	current := Describe(cmd)
+
+	if os.Getenv(UpdateCompatibleEnvironmentVariable) != "" {
+		if err := saveDescription(current, path); err != nil {
+			t.Errorf("saving the command description to %s: %v", path, err)
+			return false
+		}
+		return true
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("reading the saved command description: %v\n"+
+			"Set %s=1 to save the current command description.", err, UpdateCompatibleEnvironmentVariable)
+		return false
+	}
+
+	var saved CommandDescription
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Errorf("decoding the saved command description %s: %v", path, err)
+		return false
+	}
+
+	if err := CheckCompatible(saved, current); err != nil {
+		t.Errorf("the command is not compatible with %s:\n%v\n"+
+			"Set %s=1 to accept intentional changes.", path, err, UpdateCompatibleEnvironmentVariable)
+		return false
+	}
+
+	return true
+}
+
+// CheckCompatible returns an error if the current description of a command
+// tree is not backwards compatible with the saved one. The following changes
+// are incompatible:
+//
+//   - A command or one of its aliases is removed.
+//   - A flag available to a command, including inherited flags, is removed.
+//   - The type or shorthand of a flag is changed.
+//   - The environment variable of a flag is changed or removed.
+//   - A flag is required that was not required before, including new flags.
+//
+// The flags and commands described as builtin are left out of both
+// descriptions, as they are added by snek and cobra when the command tree is
+// executed by Run. This way, a description saved with the `describe-cli`
+// command is compatible with the same command tree created with NewCommand.
+//
+// Each incompatible change is reported as an error wrapping ErrIncompatible,
+// and the errors are joined together.
+func CheckCompatible(saved, current CommandDescription) error {
+	return errors.Join(checkCompatible(saved, current, nil, nil)...)
+}
+
+// checkCompatible returns the incompatible changes between the saved and
+// current descriptions of a command and its descendants, given the persistent
+// flags inherited from the ancestors of the saved and current commands.
+func checkCompatible(saved, current CommandDescription, savedInherited, currentInherited []FlagDescription) []error {
+	var errs []error
+	incompatible := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s: %s", ErrIncompatible, saved.Path, fmt.Sprintf(format, args...)))
+	}
+
+	for _, alias := range saved.Aliases {
+		if !slices.Contains(current.Aliases, alias) {
+			incompatible("alias %q removed", alias)
+		}
+	}
+
+	savedFlags := availableFlags(withoutBuiltinFlags(saved.Flags), savedInherited)
+	currentFlags := availableFlags(withoutBuiltinFlags(current.Flags), currentInherited)
+	for _, currentFlag := range currentFlags {
+		savedFlag, ok := findFlag(savedFlags, currentFlag.Name)
+		if currentFlag.Required && (!ok || !savedFlag.Required) {
+			incompatible("flag --%s is now required", currentFlag.Name)
+		}
+	}
+
+	for _, savedFlag := range savedFlags {
+		currentFlag, ok := findFlag(currentFlags, savedFlag.Name)
+		if !ok {
+			incompatible("flag --%s removed", savedFlag.Name)
+			continue
+		}
+
+		if savedFlag.Type != currentFlag.Type {
+			incompatible("flag --%s type changed from %q to %q", savedFlag.Name, savedFlag.Type, currentFlag.Type)
+		}
+
+		if savedFlag.Shorthand != currentFlag.Shorthand {
+			incompatible("flag --%s shorthand changed from %q to %q",
+				savedFlag.Name, savedFlag.Shorthand, currentFlag.Shorthand)
+		}
+
+		if savedFlag.EnvironmentVariable != "" && savedFlag.EnvironmentVariable != currentFlag.EnvironmentVariable {
+			incompatible("flag --%s environment variable changed from %q to %q",
+				savedFlag.Name, savedFlag.EnvironmentVariable, currentFlag.EnvironmentVariable)
+		}
+	}
+
+	savedInherited = persistentFlags(savedFlags)
+	currentInherited = persistentFlags(currentFlags)
+	for _, savedChild := range saved.Commands {
+		if savedChild.Builtin {
+			continue
+		}
+
+		index := slices.IndexFunc(current.Commands, func(child CommandDescription) bool {
+			return child.Name == savedChild.Name && !child.Builtin
+		})
+		if index < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s: command removed", ErrIncompatible, savedChild.Path))
+			continue
+		}
+
+		errs = append(errs, checkCompatible(savedChild, current.Commands[index], savedInherited, currentInherited)...)
+	}
+
+	return errs
+}
+
+// withoutBuiltinFlags returns the flags that are not builtin.
+func withoutBuiltinFlags(flags []FlagDescription) []FlagDescription {
+	return slices.DeleteFunc(slices.Clone(flags), func(flag FlagDescription) bool {
+		return flag.Builtin
+	})
+}
+
+// availableFlags returns the flags defined on a command followed by the
+// inherited flags that are not overridden by the command.
+func availableFlags(local, inherited []FlagDescription) []FlagDescription {
+	flags := slices.Clone(local)
+	for _, flag := range inherited {
+		if _, ok := findFlag(local, flag.Name); !ok {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// persistentFlags returns the flags that are inherited by the subcommands of a
+// command, given the flags available to the command.
+func persistentFlags(flags []FlagDescription) []FlagDescription {
+	return slices.DeleteFunc(slices.Clone(flags), func(flag FlagDescription) bool {
+		return !flag.Persistent
+	})
+}
+
+// findFlag returns the flag with the specified name.
+func findFlag(flags []FlagDescription, name string) (FlagDescription, bool) {
+	index := slices.IndexFunc(flags, func(flag FlagDescription) bool {
+		return flag.Name == name
+	})
+	if index < 0 {
+		return FlagDescription{}, false
+	}
+	return flags[index], true
+}
+
+// saveDescription writes the description as indented JSON to the specified
+// path, creating its directory if it does not exist.
+func saveDescription(desc CommandDescription, path string) error {
+	data, err := json.MarshalIndent(desc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,220 @@
+package snek_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func newCompatibleTestDescription() snek.CommandDescription {
+	return snek.CommandDescription{
+		Name: "app",
+		Path: "app",
+		Flags: []snek.FlagDescription{
+			{Name: "verbose", Shorthand: "v", Type: "bool", Default: "false", Persistent: true},
+		},
+		Commands: []snek.CommandDescription{
+			{
+				Name:    "get",
+				Path:    "app get",
+				Aliases: []string{"g"},
+				Flags: []snek.FlagDescription{
+					{Name: "name", Shorthand: "n", Type: "string", EnvironmentVariable: "APP_NAME"},
+				},
+			},
+			{Name: "list", Path: "app list"},
+		},
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(desc *snek.CommandDescription)
+		err    string
+	}{
+		{
+			name:   "unchanged",
+			change: func(desc *snek.CommandDescription) {},
+		},
+		{
+			name: "flag added",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands[1].Flags = append(desc.Commands[1].Flags,
+					snek.FlagDescription{Name: "all", Type: "bool"})
+			},
+		},
+		{
+			name: "command added",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands = append(desc.Commands, snek.CommandDescription{Name: "watch", Path: "app watch"})
+			},
+		},
+		{
+			name: "command removed",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands = desc.Commands[:1]
+			},
+			err: "app list: command removed",
+		},
+		{
+			name: "alias removed",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands[0].Aliases = nil
+			},
+			err: `app get: alias "g" removed`,
+		},
+		{
+			name: "flag removed",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands[0].Flags = nil
+			},
+			err: "app get: flag --name removed",
+		},
+		{
+			name: "inherited flag removed",
+			change: func(desc *snek.CommandDescription) {
+				desc.Flags[0].Persistent = false
+			},
+			err: "app get: flag --verbose removed",
+		},
+		{
+			name: "type changed",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands[0].Flags[0].Type = "int"
+			},
+			err: `app get: flag --name type changed from "string" to "int"`,
+		},
+		{
+			name: "shorthand changed",
+			change: func(desc *snek.CommandDescription) {
+				desc.Flags[0].Shorthand = "V"
+			},
+			err: `app: flag --verbose shorthand changed from "v" to "V"`,
+		},
+		{
+			name: "environment variable changed",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands[0].Flags[0].EnvironmentVariable = "APP_GET_NAME"
+			},
+			err: `app get: flag --name environment variable changed from "APP_NAME" to "APP_GET_NAME"`,
+		},
+		{
+			name: "flag required",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands[0].Flags[0].Required = true
+			},
+			err: "app get: flag --name is now required",
+		},
+		{
+			name: "required flag added",
+			change: func(desc *snek.CommandDescription) {
+				desc.Commands[1].Flags = append(desc.Commands[1].Flags,
+					snek.FlagDescription{Name: "all", Type: "bool", Required: true})
+			},
+			err: "app list: flag --all is now required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := newCompatibleTestDescription()
+			tt.change(&current)
+			err := snek.CheckCompatible(newCompatibleTestDescription(), current)
+			if tt.err == "" {
+				assert.NoError(t, err, "CheckCompatible should not return an error")
+				return
+			}
+
+			require.ErrorIs(t, err, snek.ErrIncompatible, "CheckCompatible should return ErrIncompatible")
+			assert.Contains(t, err.Error(), tt.err, "The error should describe the change")
+		})
+	}
+}
+
+func TestAssertCompatible(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "cli.golden.json")
+
+	recorder := &recordingT{}
+	assert.False(t, snek.AssertCompatible(recorder, newDescribeTestCommand(t), path),
+		"AssertCompatible should fail without a saved description")
+	require.Len(t, recorder.errors, 1, "AssertCompatible should report an error")
+	assert.Contains(t, recorder.errors[0], snek.UpdateCompatibleEnvironmentVariable,
+		"The error should explain how to save the description")
+
+	t.Setenv(snek.UpdateCompatibleEnvironmentVariable, "1")
+	recorder = &recordingT{}
+	assert.True(t, snek.AssertCompatible(recorder, newDescribeTestCommand(t), path),
+		"AssertCompatible should save the description in update mode")
+	assert.Empty(t, recorder.errors, "AssertCompatible should not report an error in update mode")
+	_, err := os.Stat(path)
+	require.NoError(t, err, "The description should be saved")
+
+	t.Setenv(snek.UpdateCompatibleEnvironmentVariable, "")
+	recorder = &recordingT{}
+	assert.True(t, snek.AssertCompatible(recorder, newDescribeTestCommand(t), path),
+		"AssertCompatible should pass for an unchanged command")
+	assert.Empty(t, recorder.errors, "AssertCompatible should not report an error for an unchanged command")
+
+	var token string
+	recorder = &recordingT{}
+	assert.False(t, snek.AssertCompatible(recorder,
+		newDescribeTestCommand(t,
+			snek.WithPersistentFlag(snek.WithStringVar(&token, "token", "", "The API token")),
+			snek.WithRequiredFlag("token"),
+		), path),
+		"AssertCompatible should fail for an incompatible command")
+	require.Len(t, recorder.errors, 1, "AssertCompatible should report an error")
+	assert.Contains(t, recorder.errors[0], "app: flag --token is now required",
+		"The error should describe the change")
+}
+
+func TestAssertCompatible_DescribeCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cli.golden.json")
+	var out bytes.Buffer
+	cfg := snek.NewConfig(
+		snek.WithLogOutput(io.Discard),
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithDryRunFlag(true),
+		snek.WithOutputFlag(true),
+	)
+	err := snek.Run([]string{"describe-cli"}, cfg, describeTestInitializers(t,
+		snek.WithSubCommandGenerator(snek.NewDescribeCommand),
+		func(cmd *cobra.Command) error {
+			cmd.SetOut(&out)
+			return nil
+		},
+	)...)
+	require.NoError(t, err, "Run should not return an error")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0o644), "WriteFile should not return an error")
+
+	recorder := &recordingT{}
+	assert.True(t, snek.AssertCompatible(recorder, newDescribeTestCommand(t, snek.WithSubCommandGenerator(snek.NewDescribeCommand)), path),
+		"A description saved by the describe command should be compatible with the command created with NewCommand")
+	assert.Empty(t, recorder.errors, "AssertCompatible should not report the flags and commands added by snek and cobra")
+
+	recorder = &recordingT{}
+	assert.False(t, snek.AssertCompatible(recorder, newDescribeTestCommand(t), path),
+		"A command removed from the application should still be reported")
+	require.Len(t, recorder.errors, 1, "AssertCompatible should report an error")
+	assert.Contains(t, recorder.errors[0], "app describe-cli: command removed", "The error should describe the change")
+}
@@ -0,0 +1,202 @@
+package snek
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completionShells are the shells supported by the completion command, along
+// with the instructions for installing the generated script.
+var completionShells = []struct {
+	name         string
+	instructions string
+	generate     func(root *Command, out io.Writer, descriptions bool) error
+}{
+	{
+		name: "bash",
+		instructions: `This script depends on the 'bash-completion' package. If it is not installed
+already, you can install it via your OS's package manager.
+
+To load completions in your current shell session:
+
+	source <(%[1]s completion bash)
+
+To load completions for every new session, execute once:
+
+	# Linux
+	%[1]s completion bash > /etc/bash_completion.d/%[1]s
+
+	# macOS
+	%[1]s completion bash > $(brew --prefix)/etc/bash_completion.d/%[1]s
+
+You will need to start a new shell for this setup to take effect.`,
+		generate: func(root *Command, out io.Writer, descriptions bool) error {
+			return root.GenBashCompletionV2(out, descriptions)
+		},
+	},
+	{
+		name: "zsh",
+		instructions: `If shell completion is not already enabled in your environment you will need
+to enable it. You can execute the following once:
+
+	echo "autoload -U compinit; compinit" >> ~/.zshrc
+
+To load completions in your current shell session:
+
+	source <(%[1]s completion zsh)
+
+To load completions for every new session, execute once:
+
+	# Linux
+	%[1]s completion zsh > "${fpath[1]}/_%[1]s"
+
+	# macOS
+	%[1]s completion zsh > $(brew --prefix)/share/zsh/site-functions/_%[1]s
+
+You will need to start a new shell for this setup to take effect.`,
+		generate: func(root *Command, out io.Writer, descriptions bool) error {
+			if descriptions {
+				return root.GenZshCompletion(out)
+			}
+			return root.GenZshCompletionNoDesc(out)
+		},
+	},
+	{
+		name: "fish",
+		instructions: `To load completions in your current shell session:
+
+	%[1]s completion fish | source
+
+To load completions for every new session, execute once:
+
+	%[1]s completion fish > ~/.config/fish/completions/%[1]s.fish
+
+You will need to start a new shell for this setup to take effect.`,
+		generate: func(root *Command, out io.Writer, descriptions bool) error {
+			return root.GenFishCompletion(out, descriptions)
+		},
+	},
+	{
+		name: "powershell",
+		instructions: `To load completions in your current shell session:
+
+	%[1]s completion powershell | Out-String | Invoke-Expression
+
+To load completions for every new session, add the output of the above command
+to your powershell profile.`,
+		generate: func(root *Command, out io.Writer, descriptions bool) error {
+			if descriptions {
+				return root.GenPowerShellCompletionWithDesc(out)
+			}
+			return root.GenPowerShellCompletion(out)
+		},
+	},
+}
+
+// WithArgsCompletion sets the function used to compute the shell completions
+// for the positional arguments of the command. The function is passed the
+// command context and the partial argument being completed, and returns the
+// candidates. File names are not offered as completions.
+func WithArgsCompletion(complete func(ctx context.Context, prefix string) []string) Initializer {
+	return func(cmd *Command) error {
+		cmd.ValidArgsFunction = func(cmd *Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			return complete(ctx, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil
+	}
+}
+
+// WithFlagFilename marks the specified flag of the command as accepting a file
+// name, so only files with the specified extensions are offered as shell
+// completions. If no extensions are specified, then all files are offered. The
+// flag must be added to the command before it is marked.
+func WithFlagFilename(name string, extensions ...string) Initializer {
+	return func(cmd *Command) error {
+		if cmd.PersistentFlags().Lookup(name) != nil {
+			return cmd.MarkPersistentFlagFilename(name, extensions...)
+		}
+		return cmd.MarkFlagFilename(name, extensions...)
+	}
+}
+
+// registerEnumCompletions registers a shell completion function offering the
+// choices of each enum flag of the command that does not already have one.
+func registerEnumCompletions(cmd *Command) error {
+	var err error
+	register := func(flag *pflag.Flag) {
+		choices, ok := flag.Annotations[FlagAnnotationEnum]
+		if !ok || err != nil {
+			return
+		}
+
+		if _, exists := cmd.GetFlagCompletionFunc(flag.Name); exists {
+			return
+		}
+
+		err = cmd.RegisterFlagCompletionFunc(flag.Name,
+			cobra.FixedCompletions(choices, cobra.ShellCompDirectiveNoFileComp))
+	}
+
+	cmd.Flags().VisitAll(register)
+	cmd.PersistentFlags().VisitAll(register)
+	return err
+}
+
+// newCompletionCommand creates the completion command for the specified root
+// command, with a subcommand generating the completion script for each of the
+// supported shells.
+func newCompletionCommand(root *Command) (*Command, error) {
+	initializers := []Initializer{
+		WithUse("completion"),
+		WithShort("Generate the autocompletion script for the specified shell"),
+		WithLong(fmt.Sprintf(`Generate the autocompletion script for %s for the specified shell.
+
+See each sub-command's help for details on how to install the generated script.`, root.Name())),
+		func(cmd *Command) error {
+			cmd.Args = cobra.NoArgs
+			cmd.ValidArgsFunction = cobra.NoFileCompletions
+			cmd.Annotations = map[string]string{commandAnnotationBuiltin: "true"}
+			if ext := lookupExtensions(root); ext != nil {
+				cmd.GroupID = ext.completionCommandGroupID
+			}
+
+			// Skip any persistent hooks of the root command, such as logging
+			// setup, so nothing but the script is written to the output.
+			cmd.PersistentPreRunE = func(*Command, []string) error { return nil }
+			return nil
+		},
+	}
+
+	for _, shell := range completionShells {
+		var noDescriptions bool
+		initializers = append(initializers, WithSubCommandGenerator(func() (*Command, error) {
+			return NewCommand(
+				WithUse(shell.name),
+				WithShort(fmt.Sprintf("Generate the autocompletion script for %s", shell.name)),
+				WithLong(fmt.Sprintf("Generate the autocompletion script for %s.\n\n", shell.name)+
+					fmt.Sprintf(shell.instructions, root.Name())),
+				WithFlag(WithBoolVar(&noDescriptions, "no-descriptions", false, "disable completion descriptions")),
+				WithRunE(func(cmd *Command, args []string) error {
+					return shell.generate(cmd.Root(), cmd.OutOrStdout(), !noDescriptions)
+				}),
+				func(cmd *Command) error {
+					cmd.Args = cobra.NoArgs
+					cmd.ValidArgsFunction = cobra.NoFileCompletions
+					cmd.DisableFlagsInUseLine = true
+					return nil
+				},
+			)
+		}))
+	}
+
+	return NewCommand(initializers...)
+}
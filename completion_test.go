@@ -0,0 +1,146 @@
+package snek_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func runCompletionTest(t *testing.T, cfg *snek.Config, args []string, initializers ...snek.Initializer) string {
+	t.Helper()
+	var out bytes.Buffer
+	initializers = append([]snek.Initializer{
+		snek.WithUse("app"),
+		func(cmd *cobra.Command) error {
+			cmd.SetOut(&out)
+			return nil
+		},
+	}, initializers...)
+
+	snek.WithLogOutput(io.Discard)(cfg)
+	err := snek.Run(args, cfg, initializers...)
+	require.NoError(t, err, "Run should not return an error")
+	return out.String()
+}
+
+func TestWithCompletionCommand(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.CompletionCommand, "CompletionCommand should be false")
+	cfg = snek.NewConfig(snek.WithCompletionCommand(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.CompletionCommand, "CompletionCommand should be true")
+}
+
+func TestRun_CompletionCommand(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			out := runCompletionTest(t, snek.NewConfig(snek.WithCompletionCommand(true)),
+				[]string{"completion", shell},
+				snek.WithRun(func(*cobra.Command, []string) {}),
+			)
+			assert.Contains(t, out, "app", "The script should be generated for the root command")
+			assert.NotContains(t, out, "Logging initialized.", "The script should not contain log output")
+		})
+	}
+}
+
+func TestRun_CompletionCommand_Instructions(t *testing.T) {
+	out := runCompletionTest(t, snek.NewConfig(snek.WithCompletionCommand(true)),
+		[]string{"completion", "bash", "--help"},
+	)
+	assert.Contains(t, out, "source <(app completion bash)", "The help should include installation instructions")
+}
+
+func TestRun_CompletionCommand_Help(t *testing.T) {
+	tests := map[string]bool{
+		"enabled":  true,
+		"disabled": false,
+	}
+
+	for name, enabled := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := runCompletionTest(t, snek.NewConfig(snek.WithCompletionCommand(enabled)),
+				[]string{"--help"},
+				snek.WithRun(func(*cobra.Command, []string) {}),
+			)
+			assert.Equal(t, enabled, strings.Contains(out, "completion"),
+				"The completion command should only be listed in the help of a root without subcommands when enabled")
+		})
+	}
+}
+
+func TestRun_Completion_EnumFlag(t *testing.T) {
+	var format string
+	out := runCompletionTest(t, snek.NewConfig(),
+		[]string{"__complete", "--format", ""},
+		snek.WithFlag(snek.WithEnumVar(&format, "format", "", []string{"json", "table"}, "The format")),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	assert.Equal(t, []string{"json", "table", ":4"}, completionLines(out),
+		"The choices of the enum flag should be offered")
+}
+
+func TestRun_Completion_ValidArgs(t *testing.T) {
+	out := runCompletionTest(t, snek.NewConfig(),
+		[]string{"__complete", ""},
+		snek.WithValidArgs("start", "stop"),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	assert.Equal(t, []string{"start", "stop", ":4"}, completionLines(out),
+		"The valid args should be offered")
+}
+
+func TestWithArgsCompletion(t *testing.T) {
+	out := runCompletionTest(t, snek.NewConfig(),
+		[]string{"__complete", "st"},
+		snek.WithArgsCompletion(func(ctx context.Context, prefix string) []string {
+			assert.NotNil(t, ctx, "The completion function should be passed a context")
+			var candidates []string
+			for _, candidate := range []string{"start", "status", "delete"} {
+				if strings.HasPrefix(candidate, prefix) {
+					candidates = append(candidates, candidate)
+				}
+			}
+			return candidates
+		}),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	assert.Equal(t, []string{"start", "status", ":4"}, completionLines(out),
+		"The computed candidates should be offered")
+}
+
+func TestWithFlagFilename(t *testing.T) {
+	var local, persistent string
+	cmd, err := snek.NewCommand(
+		snek.WithFlag(snek.WithStringVar(&local, "local", "", "local")),
+		snek.WithPersistentFlag(snek.WithStringVar(&persistent, "persistent", "", "persistent")),
+		snek.WithFlagFilename("local", "yaml", "json"),
+		snek.WithFlagFilename("persistent"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Equal(t, []string{"yaml", "json"}, cmd.Flags().Lookup("local").Annotations[cobra.BashCompFilenameExt],
+		"The local flag should be marked as a file name")
+	assert.Contains(t, cmd.PersistentFlags().Lookup("persistent").Annotations, cobra.BashCompFilenameExt,
+		"The persistent flag should be marked as a file name")
+}
+
+func TestWithFlagFilename_Error(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithFlagFilename("missing"))
+	assert.Error(t, err, "NewCommand should return an error if the flag does not exist")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+// completionLines returns the lines written by the hidden __complete command,
+// without the trailing completion directive help written to stderr.
+func completionLines(out string) []string {
+	return strings.Split(strings.TrimSpace(out), "\n")
+}
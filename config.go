@@ -1,18 +1,43 @@
 package snek
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"syscall"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+
+	"github.com/ronelliott/snek/output"
 )
 
 // Config is the configuration used by snek for configuring the generated root command.
 type Config struct {
+	// CompletionCommand is true when Run should add a `completion` command to
+	// the root command that generates the autocompletion script for bash, zsh,
+	// fish and powershell, along with instructions for installing it. The
+	// command is added even when the root command has no other subcommands,
+	// and replaces the default completion command provided by cobra.
+	//
+	// The default value is false.
+	CompletionCommand bool
+
+	// ConfirmationEnvironmentVariableName is the name of the environment
+	// variable that will be used to skip the confirmations of the commands
+	// added with WithConfirmation and WithTypedConfirmation.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `ASSUME_YES`.
+	ConfirmationEnvironmentVariableName string
+
 	// DefaultLogFormat is the default log format to use when logging.
 	//
-	// Valid values are `formatted` and `json`.
+	// Valid values are `formatted`, `json`, `logfmt`, `short`, and the names
+	// of any formats added to LogFormats.
 	//
 	// The default value is `formatted`.
 	DefaultLogFormat string
@@ -20,16 +45,203 @@ type Config struct {
 	// DefaultLogLevel is the default log level to use when logging.
 	//
 	// Valid values are `debug`, `error`, `fatal`, `info`, `panic`, `trace`, and `warn`.
+	// The levels of components can be added as comma separated `name=level`
+	// pairs, such as `info,db=debug,http=warn`.
 	//
 	// The default value is `info`.
 	DefaultLogLevel string
 
+	// DefaultOutputFormat is the default output format used by Print when the
+	// output flags are added with OutputFlag.
+	//
+	// Valid values are `json`, `jsonl`, `jsonpath=EXPRESSION`, `table`,
+	// `template=TEMPLATE`, and `yaml`.
+	//
+	// The default value is `table`.
+	DefaultOutputFormat string
+
+	// DryRunEnvironmentVariableName is the name of the environment variable
+	// that will be used to set the dry run flag when DryRunFlag is true.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `DRY_RUN`.
+	DryRunEnvironmentVariableName string
+
+	// DryRunFlag is true when Run should add a `--dry-run` persistent flag to
+	// the root command, which can also be set with the environment variable
+	// named by DryRunEnvironmentVariableName. When the flag is set,
+	// IsDryRun returns true for the context of the executing command, and every
+	// log line has a `dry_run` field set to true, so rehearsals can be told
+	// apart from real runs.
+	//
+	// The default value is false.
+	DryRunFlag bool
+
+	// EnvironmentVariableCheck is true when Run should warn about every
+	// environment variable that starts with EnvironmentVariablePrefix but is not
+	// an environment variable known to snek, such as a misspelled variable. The
+	// known environment variables are those of the flags in the command tree,
+	// including the log flags, and KnownEnvironmentVariables.
+	//
+	// If EnvironmentVariablePrefix is empty, then no environment variables are
+	// checked.
+	//
+	// The default value is false.
+	EnvironmentVariableCheck bool
+
 	// EnvironmentVariablePrefix is the prefix that will be prepended to all
 	// environment variables used by snek.
 	//
 	// The default value is an empty string.
 	EnvironmentVariablePrefix string
 
+	// GlobalLogger is true when Run should also set the global zerolog logger
+	// and level to the logger it creates for the command, as snek did before
+	// loggers were scoped to each call to Run. The global logger is shared by
+	// every call to Run in the process, so concurrent calls overwrite each
+	// other. Commands should log with Logger or zerolog.Ctx instead.
+	//
+	// The default value is false.
+	GlobalLogger bool
+
+	// HelpColor is when the help output is colored by the snek help renderer.
+	//
+	// Valid values are `auto`, `always`, and `never`. When set to `auto`, the
+	// help output is colored when it is written to a terminal and the
+	// `NO_COLOR` environment variable is not set.
+	//
+	// The default value is `auto`.
+	HelpColor string
+
+	// HelpRenderer is true when Run should render the help and usage of every
+	// command with the snek help renderer, which shows the default value and
+	// environment variable of each flag, wraps the output to the width of the
+	// terminal, and colors the output using HelpTheme.
+	//
+	// The default value is false.
+	HelpRenderer bool
+
+	// HelpTemplate is the template used by the snek help renderer to render the
+	// help of a command.
+	//
+	// If the template is an empty string, then DefaultHelpTemplate is used.
+	//
+	// The default value is an empty string.
+	HelpTemplate string
+
+	// HelpTheme is the colors used by the snek help renderer.
+	//
+	// The default value is DefaultHelpTheme.
+	HelpTheme HelpTheme
+
+	// HelpWidth is the width the snek help renderer wraps the help output to.
+	//
+	// If the width is zero, then the width of the terminal is used, or 80 when
+	// the help output is not written to a terminal.
+	//
+	// The default value is zero.
+	HelpWidth int
+
+	// KnownEnvironmentVariables are the environment variables, including the
+	// prefix, that are read by the application without a flag and are
+	// therefore not warned about by EnvironmentVariableCheck.
+	//
+	// The default value is an empty slice.
+	KnownEnvironmentVariables []string
+
+	// LogCaller is true when Run should add a `caller` field with the file and
+	// line of the call that logged to every log line. It can also be set with
+	// the environment variable named by LogCallerEnvironmentVariableName.
+	//
+	// The default value is false.
+	LogCaller bool
+
+	// LogCallerEnvironmentVariableName is the name of the environment variable
+	// that will be used to enable or disable the `caller` log field.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `LOG_CALLER`.
+	LogCallerEnvironmentVariableName string
+
+	// LogCommand is true when Run should add a `command` field with the path
+	// of the executing command, such as `app serve`, to every log line. It can
+	// also be set with the environment variable named by
+	// LogCommandEnvironmentVariableName.
+	//
+	// The default value is false.
+	LogCommand bool
+
+	// LogCommandEnvironmentVariableName is the name of the environment variable
+	// that will be used to enable or disable the `command` log field.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `LOG_COMMAND`.
+	LogCommandEnvironmentVariableName string
+
+	// LogDeduplication is the window in which logs repeating the previous
+	// log, with the same level, message and fields other than the time and
+	// caller, are suppressed. The number of suppressed logs is written as a
+	// summary, such as `Suppressed 42 similar messages.`, before the next
+	// different log, before the next repeated log once the window has passed,
+	// and when the command has finished.
+	//
+	// If the window is zero, then logs are not deduplicated.
+	//
+	// The default value is zero.
+	LogDeduplication time.Duration
+
+	// LogFile is the file logs are written to instead of LogOutput, which can
+	// be overridden with the log file flag added when LogFileFlag is true or
+	// with the environment variable named by LogFileEnvironmentVariableName.
+	// Log files are rotated according to LogFileRotation, and logs in the
+	// `formatted` format are written to them without colors.
+	//
+	// If the file is an empty string, then logs are written to LogOutput.
+	//
+	// The default value is an empty string.
+	LogFile string
+
+	// LogFileCommandLineVariableHelp is the help text for the command line
+	// variable that will be used to set the log file when LogFileFlag is
+	// true.
+	LogFileCommandLineVariableHelp string
+
+	// LogFileCommandLineVariableLongName is the long name of the command line
+	// variable that will be used to set the log file when LogFileFlag is
+	// true.
+	//
+	// The default value is `log-file`.
+	LogFileCommandLineVariableLongName string
+
+	// LogFileEnvironmentVariableName is the name of the environment variable
+	// that will be used to set the log file.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `LOG_FILE`.
+	LogFileEnvironmentVariableName string
+
+	// LogFileFlag is true when Run should add the log file flag, named by
+	// LogFileCommandLineVariableLongName, to the root command as a persistent
+	// flag. If the root command already has a flag with the name, then Run
+	// returns an ErrFlagDuplicate error instead of adding it.
+	//
+	// The default value is false.
+	LogFileFlag bool
+
+	// LogFileRotation is when the log file is rotated and how many rotated
+	// files are kept.
+	//
+	// The default value is DefaultLogFileRotation.
+	LogFileRotation LogFileRotation
+
 	// LogFormatCommandLineVariableHelp is the help text for the command line
 	// variable that will be used to set the log format.
 	LogFormatCommandLineVariableHelp string
@@ -65,6 +277,29 @@ type Config struct {
 	// The default value is `LOG_FORMAT`.
 	LogFormatEnvironmentVariableName string
 
+	// LogFormats are the log formats that can be used, keyed by the name used
+	// to select them with the log format flag, DefaultLogFormat, or the format
+	// of a log sink.
+	//
+	// The default value is DefaultLogFormats.
+	LogFormats map[string]LogFormat
+
+	// LogHostname is true when Run should add a `hostname` field with the name
+	// of the host to every log line. It can also be set with the environment
+	// variable named by LogHostnameEnvironmentVariableName.
+	//
+	// The default value is false.
+	LogHostname bool
+
+	// LogHostnameEnvironmentVariableName is the name of the environment variable
+	// that will be used to enable or disable the `hostname` log field.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `LOG_HOSTNAME`.
+	LogHostnameEnvironmentVariableName string
+
 	// LogLevelCommandLineVariableHelp is the help text for the command line
 	// variable that will be used to set the log level.
 	LogLevelCommandLineVariableHelp string
@@ -100,10 +335,193 @@ type Config struct {
 	// The default value is `LOG_LEVEL`.
 	LogLevelEnvironmentVariableName string
 
-	// LogOutput is the output that will be used for logging.
+	// LogOutput is the output that will be used for logging, in the format
+	// and at the level set by the log flags. It is the primary log sink.
+	//
+	// When the output is `os.Stdout`, logs are written to `os.Stderr` instead
+	// if SeparateOutput is true or the output format is not a table.
 	//
 	// The default value is `os.Stdout`.
 	LogOutput io.Writer
+
+	// LogPID is true when Run should add a `pid` field with the ID of the
+	// process to every log line. It can also be set with the environment
+	// variable named by LogPIDEnvironmentVariableName.
+	//
+	// The default value is false.
+	LogPID bool
+
+	// LogPIDEnvironmentVariableName is the name of the environment variable
+	// that will be used to enable or disable the `pid` log field.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `LOG_PID`.
+	LogPIDEnvironmentVariableName string
+
+	// LogSampling is the sampling of the logs of each level, such as `debug`,
+	// which limits how many logs of the level are written. Only the `trace`,
+	// `debug`, `info`, `warn`, and `error` levels can be sampled. The sampling
+	// of a level can be overridden with the log sampling flag added when
+	// LogSamplingFlag is true.
+	//
+	// The default value is an empty map, which writes every log.
+	LogSampling map[string]LogSampling
+
+	// LogSamplingCommandLineVariableHelp is the help text for the command line
+	// variable that will be used to set the log sampling when LogSamplingFlag
+	// is true.
+	LogSamplingCommandLineVariableHelp string
+
+	// LogSamplingCommandLineVariableLongName is the long name of the command
+	// line variable that will be used to set the log sampling when
+	// LogSamplingFlag is true.
+	//
+	// The default value is `log-sampling`.
+	LogSamplingCommandLineVariableLongName string
+
+	// LogSamplingEnvironmentVariableName is the name of the environment
+	// variable that will be used to set the log sampling when LogSamplingFlag
+	// is true.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `LOG_SAMPLING`.
+	LogSamplingEnvironmentVariableName string
+
+	// LogSamplingFlag is true when Run should add the log sampling flag,
+	// named by LogSamplingCommandLineVariableLongName, to the root command as
+	// a persistent flag, which can also be set with the environment variable
+	// named by LogSamplingEnvironmentVariableName. The
+	// flag sets the sampling of levels as comma separated `level=sampling`
+	// pairs, where the sampling is `N` to write every Nth log, `burst/period`
+	// to write a burst of logs in each period, or `burst/period:N` to write
+	// every Nth log once the burst is exhausted, such as
+	// `debug=100/1s:10,trace=1000`.
+	//
+	// The default value is false.
+	LogSamplingFlag bool
+
+	// LogSinks are the destinations logs are written to in addition to
+	// LogOutput, each with its own format and minimum level. Like LogOutput,
+	// sinks writing to `os.Stdout` write to `os.Stderr` instead if
+	// SeparateOutput is true or the output format is not a table.
+	//
+	// The default value is an empty slice.
+	LogSinks []LogSink
+
+	// LogVersion is true when Run should add a `version` field to every log
+	// line with the version of the root command set with WithVersion, or the
+	// version of the main module from its build information when the command
+	// has no version. It can also be set with the environment variable named
+	// by LogVersionEnvironmentVariableName.
+	//
+	// The default value is false.
+	LogVersion bool
+
+	// LogVersionEnvironmentVariableName is the name of the environment variable
+	// that will be used to enable or disable the `version` log field.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `LOG_VERSION`.
+	LogVersionEnvironmentVariableName string
+
+	// Middleware is the middleware applied by Run to every runnable command in
+	// the generated command tree. Global middleware wraps any middleware added
+	// to the command itself with WithMiddleware.
+	//
+	// The default value is an empty slice.
+	Middleware []Middleware
+
+	// NoInputEnvironmentVariableName is the name of the environment variable
+	// that will be used to disable interactive prompts when Prompt is true.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `NO_INPUT`.
+	NoInputEnvironmentVariableName string
+
+	// OutputEnvironmentVariableName is the name of the environment variable
+	// that will be used to set the output format when the output flags are
+	// added with OutputFlag.
+	//
+	// The configured environment variable prefix will be prepended to the
+	// configured environment variable name.
+	//
+	// The default value is `OUTPUT`.
+	OutputEnvironmentVariableName string
+
+	// OutputFlag is true when Run should add the `--output`/`-o`, `--columns`
+	// and `--no-headers` persistent flags to the root command, which select how
+	// Print renders values.
+	//
+	// The default value is false.
+	OutputFlag bool
+
+	// Prompt is true when Run should prompt for the value of every required
+	// flag of the executing command that is neither set on the command line
+	// nor by its environment variable, when the input is a terminal. Run also
+	// adds a `--no-input` persistent flag to the root command, which can also
+	// be set with the environment variable named by
+	// NoInputEnvironmentVariableName, that disables every prompt.
+	//
+	// The default value is false.
+	Prompt bool
+
+	// SeparateOutput is true when logs configured to be written to os.Stdout,
+	// by LogOutput or by LogSinks, should be written to os.Stderr instead, so
+	// the data printed by commands with Print or Out is the only output
+	// written to os.Stdout. Logs are also written to os.Stderr when the output
+	// format selected with the output flag is not a table, regardless of this
+	// value.
+	//
+	// The default value is false.
+	SeparateOutput bool
+
+	// SlogHandler is true when Run should set the default log/slog logger to
+	// one that writes to the logger set up by Run while the command executes,
+	// so logs written with log/slog, such as by libraries, honor the log level,
+	// format, output and fields. The previous default logger is restored once
+	// the command has finished executing.
+	//
+	// Like GlobalLogger, this changes the state of the whole process, which
+	// affects other goroutines and concurrent calls to Run. Slog returns a
+	// log/slog logger for the executing command without changing the default
+	// log/slog logger.
+	//
+	// The default value is false.
+	SlogHandler bool
+
+	// ShutdownSignals are the signals that cancel the context of the executing
+	// command. Once one of the signals is received, the default behavior for
+	// the signals is restored, so a second signal terminates the process.
+	//
+	// If no signals are configured, then no signals are handled.
+	//
+	// The default value is `os.Interrupt` and `syscall.SIGTERM`.
+	ShutdownSignals []os.Signal
+
+	// ShutdownTimeout is the maximum amount of time the shutdown hooks
+	// registered with OnShutdown have to complete once the command has
+	// finished executing.
+	//
+	// If the timeout is zero, then the shutdown hooks are not bounded.
+	//
+	// The default value is 10 seconds.
+	ShutdownTimeout time.Duration
+
+	// UsageTemplate is the template used by the snek help renderer to render
+	// the usage of a command.
+	//
+	// If the template is an empty string, then DefaultUsageTemplate is used.
+	//
+	// The default value is an empty string.
+	UsageTemplate string
 }
 
 // Configurator is a function that can be used to configure snek.
@@ -113,18 +531,40 @@ type Configurator func(*Config)
 // is called with the Config to initialize any values within the configuration.
 func NewConfig(initializers ...Configurator) *Config {
 	cfg := &Config{
-		DefaultLogFormat:                      "formatted",
-		DefaultLogLevel:                       "info",
-		EnvironmentVariablePrefix:             "",
-		LogFormatCommandLineVariableHelp:      "The log format to use when logging. Valid values are `formatted` and `json`.",
-		LogFormatCommandLineVariableLongName:  "log-format",
-		LogFormatCommandLineVariableShortName: "",
-		LogFormatEnvironmentVariableName:      "LOG_FORMAT",
-		LogLevelCommandLineVariableHelp:       "The logging level to use. Logs with a level greater than or equal to the specified level will be logged. Valid values are `debug`, `error`, `fatal`, `info`, `panic`, `trace`, and `warn`.",
-		LogLevelCommandLineVariableLongName:   "log-level",
-		LogLevelCommandLineVariableShortName:  "",
-		LogLevelEnvironmentVariableName:       "LOG_LEVEL",
-		LogOutput:                             os.Stdout,
+		ConfirmationEnvironmentVariableName:    "ASSUME_YES",
+		DefaultLogFormat:                       "formatted",
+		DefaultLogLevel:                        "info",
+		DefaultOutputFormat:                    output.FormatTable,
+		DryRunEnvironmentVariableName:          "DRY_RUN",
+		EnvironmentVariablePrefix:              "",
+		HelpColor:                              HelpColorAuto,
+		HelpTheme:                              DefaultHelpTheme(),
+		LogCallerEnvironmentVariableName:       "LOG_CALLER",
+		LogCommandEnvironmentVariableName:      "LOG_COMMAND",
+		LogFileCommandLineVariableHelp:         "The file to write logs to. Log files are rotated once they grow too large.",
+		LogFileCommandLineVariableLongName:     "log-file",
+		LogFileEnvironmentVariableName:         "LOG_FILE",
+		LogFileRotation:                        DefaultLogFileRotation(),
+		LogFormatCommandLineVariableHelp:       "The log format to use when logging. Valid values are `formatted`, `json`, `logfmt`, and `short`.",
+		LogFormatCommandLineVariableLongName:   "log-format",
+		LogFormatCommandLineVariableShortName:  "",
+		LogFormatEnvironmentVariableName:       "LOG_FORMAT",
+		LogFormats:                             DefaultLogFormats(),
+		LogHostnameEnvironmentVariableName:     "LOG_HOSTNAME",
+		LogLevelCommandLineVariableHelp:        "The logging level to use. Logs with a level greater than or equal to the specified level will be logged. Valid values are `debug`, `error`, `fatal`, `info`, `panic`, `trace`, and `warn`. The levels of components can be added as `name=level` pairs, such as `info,db=debug`.",
+		LogLevelCommandLineVariableLongName:    "log-level",
+		LogLevelCommandLineVariableShortName:   "",
+		LogLevelEnvironmentVariableName:        "LOG_LEVEL",
+		LogOutput:                              os.Stdout,
+		LogPIDEnvironmentVariableName:          "LOG_PID",
+		LogSamplingCommandLineVariableHelp:     "Sample the logs of each level, such as debug=100/1s:10 to write 100 debug logs per second and every 10th log after that.",
+		LogSamplingCommandLineVariableLongName: "log-sampling",
+		LogSamplingEnvironmentVariableName:     "LOG_SAMPLING",
+		LogVersionEnvironmentVariableName:      "LOG_VERSION",
+		NoInputEnvironmentVariableName:         "NO_INPUT",
+		OutputEnvironmentVariableName:          "OUTPUT",
+		ShutdownSignals:                        []os.Signal{os.Interrupt, syscall.SIGTERM},
+		ShutdownTimeout:                        10 * time.Second,
 	}
 
 	for _, initializer := range initializers {
@@ -140,25 +580,88 @@ func NewConfig(initializers ...Configurator) *Config {
 // returned.
 //
 // This function checks the following:
-// - DefaultLogFormat is valid
-// - DefaultLogLevel is valid
+// - ConfirmationEnvironmentVariableName is not empty
+// - DryRunEnvironmentVariableName is not empty when DryRunFlag is true
+// - DefaultLogFormat is one of LogFormats
+// - DefaultLogLevel is valid, including the levels of its components
+// - HelpColor is valid
+// - HelpTemplate and UsageTemplate can be parsed
+// - LogCallerEnvironmentVariableName and the other log field environment variable names are not empty
+// - LogDeduplication is not negative
+// - LogFileCommandLineVariableLongName is not empty when LogFileFlag is true
+// - LogFileEnvironmentVariableName is not empty
+// - LogFileRotation has no negative values
 // - LogFormatCommandLineVariableLongName and LogFormatCommandLineVariableShortName are not both empty
 // - LogFormatEnvironmentVariableName is not empty
 // - LogLevelCommandLineVariableLongName and LogLevelCommandLineVariableShortName are not both empty
 // - LogLevelEnvironmentVariableName is not empty
 // - LogOutput is not nil
+// - LogSampling samples levels that can be sampled and writes logs
+// - LogSamplingCommandLineVariableLongName is not empty when LogSamplingFlag is true
+// - LogSamplingEnvironmentVariableName is not empty when LogSamplingFlag is true
+// - Every log sink has an output, a format that is one of LogFormats, and a valid level
+// - DefaultOutputFormat is valid and OutputEnvironmentVariableName is not empty when OutputFlag is true
+// - NoInputEnvironmentVariableName is not empty when Prompt is true
+// - ShutdownTimeout is not negative
 func (cfg *Config) validate() error {
-	switch cfg.DefaultLogFormat {
-	case LogFormatFormatted, LogFormatJson:
-	default:
+	if len(cfg.ConfirmationEnvironmentVariableName) == 0 {
+		log.Error().Msg("Confirmation environment variable name is empty")
+		return ErrConfirmationEnvironmentVariableNameEmpty
+	}
+
+	if cfg.DryRunFlag && len(cfg.DryRunEnvironmentVariableName) == 0 {
+		log.Error().Msg("Dry run environment variable name is empty")
+		return ErrDryRunEnvironmentVariableNameEmpty
+	}
+
+	if cfg.LogFormats[cfg.DefaultLogFormat] == nil {
 		log.Error().Str("format", cfg.DefaultLogFormat).Msg("Default log format is invalid")
 		return ErrLogFormatInvalid
 	}
 
-	_, err := zerolog.ParseLevel(cfg.DefaultLogLevel)
-	if err != nil {
-		log.Error().Str("level", cfg.DefaultLogLevel).Msg("Default log level is invalid")
-		return ErrLogLevelInvalid
+	if _, _, err := parseLogLevels(cfg.DefaultLogLevel, "info"); err != nil {
+		log.Error().Err(err).Str("level", cfg.DefaultLogLevel).Msg("Default log level is invalid")
+		return err
+	}
+
+	switch cfg.HelpColor {
+	case HelpColorAuto, HelpColorAlways, HelpColorNever:
+	default:
+		log.Error().Str("color", cfg.HelpColor).Msg("Help color is invalid")
+		return ErrHelpColorInvalid
+	}
+
+	if err := validateHelpTemplates(cfg); err != nil {
+		log.Error().Err(err).Msg("Help template is invalid")
+		return err
+	}
+
+	if cfg.LogDeduplication < 0 {
+		log.Error().Dur("window", cfg.LogDeduplication).Msg("Log deduplication window is negative")
+		return ErrLogDeduplicationInvalid
+	}
+
+	for _, field := range optionalLogFields {
+		if len(field.environmentVariableName(cfg)) == 0 {
+			log.Error().Str("field", field.name).Msg("Log field environment variable name is empty")
+			return fmt.Errorf("%w: %s", ErrLogFieldEnvironmentVariableNameEmpty, field.name)
+		}
+	}
+
+	if cfg.LogFileFlag && len(cfg.LogFileCommandLineVariableLongName) == 0 {
+		log.Error().Msg("Log file command line variable name is empty")
+		return ErrLogFileCommandLineVariableNameEmpty
+	}
+
+	if len(cfg.LogFileEnvironmentVariableName) == 0 {
+		log.Error().Msg("Log file environment variable name is empty")
+		return ErrLogFileEnvironmentVariableNameEmpty
+	}
+
+	rotation := cfg.LogFileRotation
+	if rotation.MaxSize < 0 || rotation.Interval < 0 || rotation.MaxAge < 0 || rotation.MaxBackups < 0 {
+		log.Error().Interface("rotation", rotation).Msg("Log file rotation is invalid")
+		return ErrLogFileRotationInvalid
 	}
 
 	if len(cfg.LogFormatCommandLineVariableLongName) == 0 && len(cfg.LogFormatCommandLineVariableShortName) == 0 {
@@ -186,9 +689,84 @@ func (cfg *Config) validate() error {
 		return ErrLogOutputEmpty
 	}
 
+	if err := validateLogSampling(cfg.LogSampling); err != nil {
+		return err
+	}
+
+	if cfg.LogSamplingFlag && len(cfg.LogSamplingCommandLineVariableLongName) == 0 {
+		log.Error().Msg("Log sampling command line variable name is empty")
+		return ErrLogSamplingCommandLineVariableNameEmpty
+	}
+
+	if cfg.LogSamplingFlag && len(cfg.LogSamplingEnvironmentVariableName) == 0 {
+		log.Error().Msg("Log sampling environment variable name is empty")
+		return ErrLogSamplingEnvironmentVariableNameEmpty
+	}
+
+	for i, sink := range cfg.LogSinks {
+		if sink.Output == nil {
+			log.Error().Int("sink", i).Msg("Log sink output is nil")
+			return fmt.Errorf("%w: log sink %d", ErrLogOutputEmpty, i)
+		}
+
+		if sink.Format != "" && cfg.LogFormats[sink.Format] == nil {
+			log.Error().Int("sink", i).Str("format", sink.Format).Msg("Log sink format is invalid")
+			return fmt.Errorf("%w: log sink %d", ErrLogFormatInvalid, i)
+		}
+
+		if _, err := zerolog.ParseLevel(sink.Level); err != nil {
+			log.Error().Int("sink", i).Str("level", sink.Level).Msg("Log sink level is invalid")
+			return fmt.Errorf("%w: log sink %d", ErrLogLevelInvalid, i)
+		}
+	}
+
+	if cfg.OutputFlag {
+		if _, err := output.ParseFormat(cfg.DefaultOutputFormat); err != nil {
+			log.Error().Err(err).Str("format", cfg.DefaultOutputFormat).Msg("Default output format is invalid")
+			return fmt.Errorf("%w: %v", ErrOutputFormatInvalid, err)
+		}
+
+		if len(cfg.OutputEnvironmentVariableName) == 0 {
+			log.Error().Msg("Output environment variable name is empty")
+			return ErrOutputEnvironmentVariableNameEmpty
+		}
+	}
+
+	if cfg.Prompt && len(cfg.NoInputEnvironmentVariableName) == 0 {
+		log.Error().Msg("No input environment variable name is empty")
+		return ErrNoInputEnvironmentVariableNameEmpty
+	}
+
+	if cfg.ShutdownTimeout < 0 {
+		log.Error().Dur("timeout", cfg.ShutdownTimeout).Msg("Shutdown timeout is negative")
+		return ErrShutdownTimeoutInvalid
+	}
+
 	return nil
 }
 
+// WithCompletionCommand sets whether Run adds a `completion` command to the
+// root command.
+//
+// The default value is false.
+func WithCompletionCommand(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.CompletionCommand = enabled
+	}
+}
+
+// WithConfirmationEnvironmentVariableName sets the name of the environment
+// variable that will be used to skip confirmations. The configured environment
+// variable prefix will be prepended to the configured environment variable
+// name.
+//
+// The default value is `ASSUME_YES`.
+func WithConfirmationEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.ConfirmationEnvironmentVariableName = name
+	}
+}
+
 // WithDefaultLogFormat sets the default log format to the provided value.
 //
 // The default value is `formatted`.
@@ -201,6 +779,8 @@ func WithDefaultLogFormat(format string) Configurator {
 // WithDefaultLogLevel sets the default log level to the provided value.
 //
 // Valid values are `debug`, `error`, `fatal`, `info`, `panic`, `trace`, and `warn`.
+// The levels of components can be added as comma separated `name=level`
+// pairs, such as `info,db=debug,http=warn`.
 //
 // The default value is `info`.
 func WithDefaultLogLevel(level string) Configurator {
@@ -209,6 +789,51 @@ func WithDefaultLogLevel(level string) Configurator {
 	}
 }
 
+// WithDefaultOutputFormat sets the default output format to the provided value.
+//
+// Valid values are `json`, `jsonl`, `jsonpath=EXPRESSION`, `table`,
+// `template=TEMPLATE`, and `yaml`.
+//
+// The default value is `table`.
+func WithDefaultOutputFormat(format string) Configurator {
+	return func(cfg *Config) {
+		cfg.DefaultOutputFormat = format
+	}
+}
+
+// WithDryRunEnvironmentVariableName sets the name of the environment variable
+// that will be used to set the dry run flag. The configured environment
+// variable prefix will be prepended to the configured environment variable
+// name.
+//
+// The default value is `DRY_RUN`.
+func WithDryRunEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.DryRunEnvironmentVariableName = name
+	}
+}
+
+// WithDryRunFlag sets whether Run adds the `--dry-run` flag to the root
+// command.
+//
+// The default value is false.
+func WithDryRunFlag(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.DryRunFlag = enabled
+	}
+}
+
+// WithEnvironmentVariableCheck sets whether Run warns about environment
+// variables that start with the environment variable prefix but are not known
+// to snek.
+//
+// The default value is false.
+func WithEnvironmentVariableCheck(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.EnvironmentVariableCheck = enabled
+	}
+}
+
 // WithEnvironmentVariablePrefix sets the environment variable prefix to the provided value.
 //
 // The default value is an empty string.
@@ -218,6 +843,202 @@ func WithEnvironmentVariablePrefix(prefix string) Configurator {
 	}
 }
 
+// WithGlobalLogger sets whether Run also sets the global zerolog logger and
+// level to the logger it creates for the command.
+//
+// The default value is false.
+func WithGlobalLogger(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.GlobalLogger = enabled
+	}
+}
+
+// WithHelpColor sets when the help output is colored by the snek help
+// renderer.
+//
+// Valid values are `auto`, `always`, and `never`.
+//
+// The default value is `auto`.
+func WithHelpColor(color string) Configurator {
+	return func(cfg *Config) {
+		cfg.HelpColor = color
+	}
+}
+
+// WithHelpRenderer sets whether Run renders the help and usage of every
+// command with the snek help renderer.
+//
+// The default value is false.
+func WithHelpRenderer(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.HelpRenderer = enabled
+	}
+}
+
+// WithHelpTemplate sets the template used by the snek help renderer to render
+// the help of a command.
+//
+// The default value is an empty string, which uses DefaultHelpTemplate.
+func WithHelpTemplate(tmpl string) Configurator {
+	return func(cfg *Config) {
+		cfg.HelpTemplate = tmpl
+	}
+}
+
+// WithHelpTheme sets the colors used by the snek help renderer.
+//
+// The default value is DefaultHelpTheme.
+func WithHelpTheme(theme HelpTheme) Configurator {
+	return func(cfg *Config) {
+		cfg.HelpTheme = theme
+	}
+}
+
+// WithHelpWidth sets the width the snek help renderer wraps the help output to.
+// If the width is zero, then the width of the terminal is used.
+//
+// The default value is zero.
+func WithHelpWidth(width int) Configurator {
+	return func(cfg *Config) {
+		cfg.HelpWidth = width
+	}
+}
+
+// WithKnownEnvironmentVariables adds the provided environment variables,
+// including the prefix, to the environment variables that are not warned about
+// by the environment variable check.
+func WithKnownEnvironmentVariables(names ...string) Configurator {
+	return func(cfg *Config) {
+		cfg.KnownEnvironmentVariables = append(cfg.KnownEnvironmentVariables, names...)
+	}
+}
+
+// WithLogCaller sets whether Run adds a `caller` field with the file and line
+// of the call that logged to every log line.
+//
+// The default value is false.
+func WithLogCaller(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.LogCaller = enabled
+	}
+}
+
+// WithLogCallerEnvironmentVariableName sets the name of the environment
+// variable that will be used to enable or disable the `caller` log field. The
+// configured environment variable prefix will be prepended to the configured
+// environment variable name.
+//
+// The default value is `LOG_CALLER`.
+func WithLogCallerEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogCallerEnvironmentVariableName = name
+	}
+}
+
+// WithLogCommand sets whether Run adds a `command` field with the path of the
+// executing command to every log line.
+//
+// The default value is false.
+func WithLogCommand(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.LogCommand = enabled
+	}
+}
+
+// WithLogCommandEnvironmentVariableName sets the name of the environment
+// variable that will be used to enable or disable the `command` log field. The
+// configured environment variable prefix will be prepended to the configured
+// environment variable name.
+//
+// The default value is `LOG_COMMAND`.
+func WithLogCommandEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogCommandEnvironmentVariableName = name
+	}
+}
+
+// WithLogDeduplication sets the window in which logs repeating the level,
+// message and fields of the previous log are suppressed and summarized.
+//
+// The default value is zero, which does not deduplicate logs.
+func WithLogDeduplication(window time.Duration) Configurator {
+	return func(cfg *Config) {
+		cfg.LogDeduplication = window
+	}
+}
+
+// WithLogFile sets the file logs are written to instead of the log output.
+//
+// The default value is an empty string, which writes logs to the log output.
+func WithLogFile(path string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogFile = path
+	}
+}
+
+// WithLogFileCommandLineVariableHelp sets the help text for the command line
+// variable that will be used to set the log file.
+func WithLogFileCommandLineVariableHelp(help string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogFileCommandLineVariableHelp = help
+	}
+}
+
+// WithLogFileCommandLineVariableLongName sets the long name of the command
+// line variable that will be used to set the log file.
+//
+// The default value is `log-file`.
+func WithLogFileCommandLineVariableLongName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogFileCommandLineVariableLongName = name
+	}
+}
+
+// WithLogFileEnvironmentVariableName sets the name of the environment variable
+// that will be used to set the log file. The configured environment variable
+// prefix will be prepended to the configured environment variable name.
+//
+// The default value is `LOG_FILE`.
+func WithLogFileEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogFileEnvironmentVariableName = name
+	}
+}
+
+// WithLogFileFlag sets whether Run adds the `--log-file` flag to the root
+// command.
+//
+// The default value is false.
+func WithLogFileFlag(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.LogFileFlag = enabled
+	}
+}
+
+// WithLogFileRotation sets when the log file is rotated and how many rotated
+// files are kept.
+//
+// The default value is DefaultLogFileRotation.
+func WithLogFileRotation(rotation LogFileRotation) Configurator {
+	return func(cfg *Config) {
+		cfg.LogFileRotation = rotation
+	}
+}
+
+// WithLogFormat adds the log format with the name, which can then be selected
+// with the log format flag, as the default log format, or as the format of a
+// log sink. A format with the same name as an existing format replaces it.
+//
+// The default value is DefaultLogFormats.
+func WithLogFormat(name string, format LogFormat) Configurator {
+	return func(cfg *Config) {
+		if cfg.LogFormats == nil {
+			cfg.LogFormats = DefaultLogFormats()
+		}
+		cfg.LogFormats[name] = format
+	}
+}
+
 // WithLogLevelCommandLineVariableHelp sets the help text for the command line
 // variable that will be used to set the log level.
 func WithLogFormatCommandLineVariableHelp(help string) Configurator {
@@ -265,6 +1086,28 @@ func WithLogFormatEnvironmentVariableName(name string) Configurator {
 	}
 }
 
+// WithLogHostname sets whether Run adds a `hostname` field with the name of the
+// host to every log line.
+//
+// The default value is false.
+func WithLogHostname(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.LogHostname = enabled
+	}
+}
+
+// WithLogHostnameEnvironmentVariableName sets the name of the environment
+// variable that will be used to enable or disable the `hostname` log field. The
+// configured environment variable prefix will be prepended to the configured
+// environment variable name.
+//
+// The default value is `LOG_HOSTNAME`.
+func WithLogHostnameEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogHostnameEnvironmentVariableName = name
+	}
+}
+
 // WithLogLevelCommandLineVariableHelp sets the help text for the command line
 // variable that will be used to set the log level.
 func WithLogLevelCommandLineVariableHelp(help string) Configurator {
@@ -312,6 +1155,14 @@ func WithLogLevelEnvironmentVariableName(name string) Configurator {
 	}
 }
 
+// WithGlobalMiddleware adds the provided middleware to the middleware that is
+// applied to every runnable command in the generated command tree.
+func WithGlobalMiddleware(middleware ...Middleware) Configurator {
+	return func(cfg *Config) {
+		cfg.Middleware = append(cfg.Middleware, middleware...)
+	}
+}
+
 // WithLogOutput sets the log output to the provided value.
 //
 // The default value is `os.Stdout`.
@@ -320,3 +1171,205 @@ func WithLogOutput(output io.Writer) Configurator {
 		cfg.LogOutput = output
 	}
 }
+
+// WithLogPID sets whether Run adds a `pid` field with the ID of the process to
+// every log line.
+//
+// The default value is false.
+func WithLogPID(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.LogPID = enabled
+	}
+}
+
+// WithLogPIDEnvironmentVariableName sets the name of the environment variable
+// that will be used to enable or disable the `pid` log field. The configured
+// environment variable prefix will be prepended to the configured environment
+// variable name.
+//
+// The default value is `LOG_PID`.
+func WithLogPIDEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogPIDEnvironmentVariableName = name
+	}
+}
+
+// WithLogSampling sets the sampling of the logs of the level, which limits how
+// many logs of the level are written.
+//
+// The default value is an empty map, which writes every log.
+func WithLogSampling(level string, sampling LogSampling) Configurator {
+	return func(cfg *Config) {
+		if cfg.LogSampling == nil {
+			cfg.LogSampling = map[string]LogSampling{}
+		}
+		cfg.LogSampling[level] = sampling
+	}
+}
+
+// WithLogSamplingCommandLineVariableHelp sets the help text for the command
+// line variable that will be used to set the log sampling.
+func WithLogSamplingCommandLineVariableHelp(help string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogSamplingCommandLineVariableHelp = help
+	}
+}
+
+// WithLogSamplingCommandLineVariableLongName sets the long name of the
+// command line variable that will be used to set the log sampling.
+//
+// The default value is `log-sampling`.
+func WithLogSamplingCommandLineVariableLongName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogSamplingCommandLineVariableLongName = name
+	}
+}
+
+// WithLogSamplingEnvironmentVariableName sets the name of the environment
+// variable that will be used to set the log sampling. The configured
+// environment variable prefix will be prepended to the configured environment
+// variable name.
+//
+// The default value is `LOG_SAMPLING`.
+func WithLogSamplingEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogSamplingEnvironmentVariableName = name
+	}
+}
+
+// WithLogSamplingFlag sets whether Run adds the `--log-sampling` flag to the
+// root command.
+//
+// The default value is false.
+func WithLogSamplingFlag(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.LogSamplingFlag = enabled
+	}
+}
+
+// WithLogSink adds the sinks to the destinations logs are written to in
+// addition to the log output, each with its own format and minimum level.
+//
+// The default value is an empty slice.
+func WithLogSink(sinks ...LogSink) Configurator {
+	return func(cfg *Config) {
+		cfg.LogSinks = append(cfg.LogSinks, sinks...)
+	}
+}
+
+// WithLogVersion sets whether Run adds a `version` field with the version of
+// the root command, or of the main module, to every log line.
+//
+// The default value is false.
+func WithLogVersion(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.LogVersion = enabled
+	}
+}
+
+// WithLogVersionEnvironmentVariableName sets the name of the environment
+// variable that will be used to enable or disable the `version` log field. The
+// configured environment variable prefix will be prepended to the configured
+// environment variable name.
+//
+// The default value is `LOG_VERSION`.
+func WithLogVersionEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.LogVersionEnvironmentVariableName = name
+	}
+}
+
+// WithNoInputEnvironmentVariableName sets the name of the environment variable
+// that will be used to disable interactive prompts. The configured environment
+// variable prefix will be prepended to the configured environment variable
+// name.
+//
+// The default value is `NO_INPUT`.
+func WithNoInputEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.NoInputEnvironmentVariableName = name
+	}
+}
+
+// WithOutputEnvironmentVariableName sets the name of the environment variable
+// that will be used to set the output format. The configured environment
+// variable prefix will be prepended to the configured environment variable
+// name.
+//
+// The default value is `OUTPUT`.
+func WithOutputEnvironmentVariableName(name string) Configurator {
+	return func(cfg *Config) {
+		cfg.OutputEnvironmentVariableName = name
+	}
+}
+
+// WithOutputFlag sets whether Run adds the `--output`/`-o`, `--columns` and
+// `--no-headers` flags to the root command.
+//
+// The default value is false.
+func WithOutputFlag(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.OutputFlag = enabled
+	}
+}
+
+// WithPrompt sets whether Run prompts for the values of missing required flags
+// and adds the `--no-input` flag to the root command.
+//
+// The default value is false.
+func WithPrompt(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.Prompt = enabled
+	}
+}
+
+// WithSeparateOutput sets whether logs configured to be written to os.Stdout
+// are written to os.Stderr instead, separating them from the data printed by
+// commands.
+//
+// The default value is false.
+func WithSeparateOutput(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.SeparateOutput = enabled
+	}
+}
+
+// WithSlogHandler sets whether Run sets the default log/slog logger to one
+// that writes to the logger set up by Run.
+//
+// The default value is false.
+func WithSlogHandler(enabled bool) Configurator {
+	return func(cfg *Config) {
+		cfg.SlogHandler = enabled
+	}
+}
+
+// WithShutdownSignals sets the signals that cancel the context of the executing
+// command. If no signals are provided, then no signals are handled.
+//
+// The default value is `os.Interrupt` and `syscall.SIGTERM`.
+func WithShutdownSignals(signals ...os.Signal) Configurator {
+	return func(cfg *Config) {
+		cfg.ShutdownSignals = signals
+	}
+}
+
+// WithShutdownTimeout sets the maximum amount of time the shutdown hooks have
+// to complete. If the timeout is zero, then the shutdown hooks are not bounded.
+//
+// The default value is 10 seconds.
+func WithShutdownTimeout(timeout time.Duration) Configurator {
+	return func(cfg *Config) {
+		cfg.ShutdownTimeout = timeout
+	}
+}
+
+// WithUsageTemplate sets the template used by the snek help renderer to render
+// the usage of a command.
+//
+// The default value is an empty string, which uses DefaultUsageTemplate.
+func WithUsageTemplate(tmpl string) Configurator {
+	return func(cfg *Config) {
+		cfg.UsageTemplate = tmpl
+	}
+}
@@ -1,7 +1,10 @@
 package snek_test
 
 import (
+	"os"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -132,3 +135,22 @@ func TestWithLogLevelEnvironmentVariableName(t *testing.T) {
 	assert.Equal(t, "LOGLEVEL", cfg.LogLevelEnvironmentVariableName,
 		"LogLevelEnvironmentVariableName should be LOGLEVEL")
 }
+
+func TestWithShutdownSignals(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, []os.Signal{os.Interrupt, syscall.SIGTERM}, cfg.ShutdownSignals,
+		"ShutdownSignals should be os.Interrupt and syscall.SIGTERM")
+	cfg = snek.NewConfig(snek.WithShutdownSignals())
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Empty(t, cfg.ShutdownSignals, "ShutdownSignals should be empty")
+}
+
+func TestWithShutdownTimeout(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, 10*time.Second, cfg.ShutdownTimeout, "ShutdownTimeout should be 10 seconds")
+	cfg = snek.NewConfig(snek.WithShutdownTimeout(time.Second))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, time.Second, cfg.ShutdownTimeout, "ShutdownTimeout should be 1 second")
+}
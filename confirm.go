@@ -0,0 +1,224 @@
+package snek
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+// confirmation is the confirmation set on a command with WithConfirmation or
+// WithTypedConfirmation.
+type confirmation struct {
+	// message is the question asked before the command runs.
+	message string
+
+	// resource returns the name of the resource that must be typed to confirm,
+	// or nil if a yes or no answer is enough.
+	resource func(cmd *Command, args []string) string
+
+	// flag is the `--yes` flag added to the command, or nil if the command
+	// already had the flag.
+	flag *pflag.Flag
+}
+
+// WithConfirmation asks for confirmation with the message before the run
+// function of the command is called, such as for commands that delete data.
+// The command only runs if the answer is yes. The `--yes`/`-y` flag is added to
+// the command to skip the confirmation, which can also be skipped with the
+// `ASSUME_YES` environment variable when the command is executed by Run,
+// prefixed with the environment variable prefix and renamed with
+// WithConfirmationEnvironmentVariableName. The `-y` shorthand is left out if
+// another flag of the command, or a persistent flag of one of its parents,
+// already uses it.
+//
+// The confirmation is asked with the prompter of the command returned by
+// Prompter. When the prompter is not interactive, such as when the input is not
+// a terminal, the command is not run and ErrConfirmationRequired is returned
+// unless the confirmation is skipped.
+func WithConfirmation(message string) Initializer {
+	return withConfirmation(&confirmation{message: message})
+}
+
+// WithTypedConfirmation asks for confirmation like WithConfirmation, except
+// that the name of the resource returned by resource must be typed to confirm,
+// which is harder to do by accident for high risk commands such as dropping a
+// database. If resource returns an empty name, then a yes or no answer is
+// enough.
+func WithTypedConfirmation(message string, resource func(cmd *Command, args []string) string) Initializer {
+	return withConfirmation(&confirmation{message: message, resource: resource})
+}
+
+// withConfirmation sets the confirmation on the command and adds the `--yes`
+// flag to it, unless the command already has the flag. The `-y` shorthand is
+// only used when no other flag of the command uses it.
+func withConfirmation(c *confirmation) Initializer {
+	return func(cmd *Command) error {
+		extensionsFor(cmd).confirmation = c
+
+		flags := cmd.Flags()
+		if flags.Lookup("yes") != nil {
+			return nil
+		}
+
+		shorthand := "y"
+		if shorthandInUse(cmd, shorthand, nil) {
+			shorthand = ""
+		}
+
+		flags.BoolP("yes", shorthand, false, "Skip the confirmation prompt and assume yes.")
+		c.flag = flags.Lookup("yes")
+		return annotateBuiltin(flags, "yes")
+	}
+}
+
+// shorthandInUse returns true if the shorthand is used by a flag of the
+// command other than the flag, whether the flag is local, persistent, or a
+// persistent flag of one of its parents that the command inherits.
+func shorthandInUse(cmd *Command, shorthand string, flag *pflag.Flag) bool {
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+		if existing := flags.ShorthandLookup(shorthand); existing != nil && existing != flag {
+			return true
+		}
+	}
+
+	for parent := cmd.Parent(); parent != nil; parent = parent.Parent() {
+		existing := parent.PersistentFlags().ShorthandLookup(shorthand)
+		if existing != nil && cmd.Flags().Lookup(existing.Name) == nil && cmd.PersistentFlags().Lookup(existing.Name) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// removeShorthand removes the shorthand of the local flag of the command. A
+// flag set cannot forget a shorthand, so the local and persistent flags of the
+// command are added to new flag sets after the shorthand is removed.
+func removeShorthand(cmd *Command, flag *pflag.Flag) {
+	flags, persistent := cmd.Flags(), cmd.PersistentFlags()
+	cmd.ResetFlags()
+	cmd.Flags().SortFlags = flags.SortFlags
+	cmd.PersistentFlags().SortFlags = persistent.SortFlags
+
+	flag.Shorthand = ""
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if persistent.Lookup(flag.Name) == nil {
+			cmd.Flags().AddFlag(flag)
+		}
+	})
+	persistent.VisitAll(cmd.PersistentFlags().AddFlag)
+}
+
+// applyConfirmation wraps the run function of the command so the confirmation
+// is asked before it is called. If the command has no confirmation, then the
+// command is not changed.
+func applyConfirmation(cmd *Command, c *confirmation) {
+	if c == nil {
+		return
+	}
+
+	applyMiddleware(cmd, []Middleware{func(next RunFunc) RunFunc {
+		return func(cmd *Command, args []string) error {
+			if err := confirm(cmd, args, c); err != nil {
+				return err
+			}
+			return next(cmd, args)
+		}
+	}})
+}
+
+// annotateConfirmations annotates the `--yes` flag of every command in the
+// command tree that asks for confirmation with the environment variable that
+// skips the confirmation. The parents of a command are only known once the
+// command tree is complete, so the `-y` shorthand added with the flag is
+// removed here if the command inherits a persistent flag that uses it, or if
+// another flag has used it since.
+func annotateConfirmations(root *Command, envVar string) error {
+	var err error
+	walkCommands(root, func(cmd *Command) {
+		ext := lookupExtensions(cmd)
+		if err != nil || ext == nil || ext.confirmation == nil {
+			return
+		}
+
+		if flag := ext.confirmation.flag; flag != nil && flag.Shorthand != "" && shorthandInUse(cmd, flag.Shorthand, flag) {
+			removeShorthand(cmd, flag)
+		}
+
+		if cmd.Flags().Lookup("yes") != nil {
+			err = annotateEnvironmentVariable(cmd.Flags(), "yes", envVar)
+		}
+	})
+	return err
+}
+
+// confirm asks for the confirmation of the command, unless it is skipped with
+// the `--yes` flag or its environment variable. If the confirmation is
+// declined, then ErrConfirmationDeclined is returned, and if it cannot be
+// asked, then ErrConfirmationRequired is returned.
+func confirm(cmd *Command, args []string, c *confirmation) error {
+	skipped, err := confirmationSkipped(cmd)
+	if err != nil || skipped {
+		return err
+	}
+
+	prompter := Prompter(cmd)
+	if !prompter.Interactive() {
+		return fmt.Errorf("%w: %s: use --yes to confirm", ErrConfirmationRequired, cmd.CommandPath())
+	}
+
+	var resource string
+	if c.resource != nil {
+		resource = c.resource(cmd, args)
+	}
+
+	if resource == "" {
+		confirmed, err := prompter.Confirm(c.message, false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("%w: %s", ErrConfirmationDeclined, cmd.CommandPath())
+		}
+		return nil
+	}
+
+	answer, err := prompter.Text(fmt.Sprintf("%s\nType %q to confirm", c.message, resource), "")
+	if err != nil {
+		return err
+	}
+	if answer != resource {
+		return fmt.Errorf("%w: %s: %q does not match %q", ErrConfirmationDeclined, cmd.CommandPath(), answer, resource)
+	}
+	return nil
+}
+
+// confirmationSkipped returns true if the `--yes` flag of the command is set,
+// or if it is not set on the command line and its environment variable is set
+// to true.
+func confirmationSkipped(cmd *Command) (bool, error) {
+	flag := cmd.Flags().Lookup("yes")
+	if flag == nil {
+		return false, nil
+	}
+
+	if flag.Changed {
+		return strconv.ParseBool(flag.Value.String())
+	}
+
+	for _, envVar := range flag.Annotations[FlagAnnotationEnvironmentVariable] {
+		value, ok := os.LookupEnv(envVar)
+		if !ok {
+			continue
+		}
+
+		skipped, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("%w: %s=%q: %v", ErrFlagEnvVarInvalid, envVar, value, err)
+		}
+		return skipped, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,251 @@
+package snek_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func runConfirmationTest(t *testing.T, cfg *snek.Config, in io.Reader, confirmation snek.Initializer, args []string) (bool, string, error) {
+	t.Helper()
+	var ran bool
+	var stderr bytes.Buffer
+	sub, err := snek.NewCommand(
+		snek.WithUse("delete"),
+		confirmation,
+		snek.WithRunE(func(*cobra.Command, []string) error {
+			ran = true
+			return nil
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	snek.WithLogOutput(io.Discard)(cfg)
+	err = snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithSubCommand(sub),
+		func(cmd *cobra.Command) error {
+			cmd.SetIn(in)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(&stderr)
+			return nil
+		},
+	)
+	return ran, stderr.String(), err
+}
+
+// nonInteractiveInput returns an input that is not a terminal, like the input
+// of a command in a CI environment.
+func nonInteractiveInput(t *testing.T) io.Reader {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err, "Pipe should not return an error")
+	require.NoError(t, w.Close(), "Close should not return an error")
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestWithConfirmation(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithUse("delete"), snek.WithConfirmation("Delete?"))
+	require.NoError(t, err, "NewCommand should not return an error")
+	flag := cmd.Flags().Lookup("yes")
+	require.NotNil(t, flag, "WithConfirmation should add the yes flag")
+	assert.Equal(t, "y", flag.Shorthand, "The yes flag should have the y shorthand")
+
+	cmd, err = snek.NewCommand(
+		snek.WithUse("delete"),
+		snek.WithFlag(snek.WithBoolVarP(new(bool), "yaml", "y", false, "Use YAML.")),
+		snek.WithConfirmation("Delete?"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Empty(t, cmd.Flags().Lookup("yes").Shorthand, "The yes flag should not take a shorthand that is in use")
+}
+
+func TestWithConfirmation_ShorthandInUse(t *testing.T) {
+	var yaml bool
+	parent, err := snek.NewCommand(
+		snek.WithUse("app"),
+		snek.WithPersistentFlag(snek.WithBoolVarP(&yaml, "yaml", "y", false, "Use YAML.")),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	cmd, err := snek.NewCommand(
+		snek.WithUse("delete"),
+		func(cmd *cobra.Command) error {
+			parent.AddCommand(cmd)
+			return nil
+		},
+		snek.WithConfirmation("Delete?"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Empty(t, cmd.Flags().Lookup("yes").Shorthand,
+		"The yes flag should not take a shorthand used by a persistent flag of a parent")
+
+	cmd, err = snek.NewCommand(
+		snek.WithUse("delete"),
+		snek.WithPersistentFlag(snek.WithBoolVarP(&yaml, "yaml", "y", false, "Use YAML.")),
+		snek.WithConfirmation("Delete?"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Empty(t, cmd.Flags().Lookup("yes").Shorthand,
+		"The yes flag should not take a shorthand used by a persistent flag of the command")
+}
+
+func TestRun_Confirmation_InheritedShorthand(t *testing.T) {
+	run := func(args []string) (bool, bool, error) {
+		var ran, yaml bool
+		sub, err := snek.NewCommand(
+			snek.WithUse("delete"),
+			snek.WithConfirmation("Delete?"),
+			snek.WithRunE(func(*cobra.Command, []string) error {
+				ran = true
+				return nil
+			}),
+		)
+		require.NoError(t, err, "NewCommand should not return an error")
+		require.Equal(t, "y", sub.Flags().Lookup("yes").Shorthand, "The shorthand should be free until the parent is known")
+
+		err = snek.Run(args, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+			snek.WithUse("app"),
+			snek.WithPersistentFlag(snek.WithBoolVarP(&yaml, "yaml", "y", false, "Use YAML.")),
+			snek.WithSubCommand(sub),
+			func(cmd *cobra.Command) error {
+				cmd.SetIn(nonInteractiveInput(t))
+				cmd.SetOut(io.Discard)
+				cmd.SetErr(io.Discard)
+				return nil
+			},
+		)
+		return ran, yaml, err
+	}
+
+	ran, yaml, err := run([]string{"delete", "-y"})
+	assert.ErrorIs(t, err, snek.ErrConfirmationRequired, "The shorthand should not skip the confirmation")
+	assert.False(t, ran, "The command should not run")
+	assert.True(t, yaml, "The shorthand should set the persistent flag of the parent")
+
+	ran, _, err = run([]string{"delete", "--yes"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, ran, "The yes flag should skip the confirmation")
+}
+
+func TestWithConfirmationEnvironmentVariableName(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "ASSUME_YES", cfg.ConfirmationEnvironmentVariableName,
+		"The default confirmation environment variable name should be ASSUME_YES")
+	cfg = snek.NewConfig(snek.WithConfirmationEnvironmentVariableName("FORCE"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "FORCE", cfg.ConfirmationEnvironmentVariableName,
+		"ConfirmationEnvironmentVariableName should be FORCE")
+}
+
+func TestRun_Confirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		args     []string
+		expected bool
+		err      error
+	}{
+		{name: "yes", input: "y\n", args: []string{"delete"}, expected: true},
+		{name: "no", input: "n\n", args: []string{"delete"}, err: snek.ErrConfirmationDeclined},
+		{name: "default", input: "\n", args: []string{"delete"}, err: snek.ErrConfirmationDeclined},
+		{name: "flag", args: []string{"delete", "--yes"}, expected: true},
+		{name: "shorthand", args: []string{"delete", "-y"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran, stderr, err := runConfirmationTest(t, snek.NewConfig(), strings.NewReader(tt.input),
+				snek.WithConfirmation("Delete everything?"), tt.args)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err, "Run should return the expected error")
+			} else {
+				assert.NoError(t, err, "Run should not return an error")
+			}
+			assert.Equal(t, tt.expected, ran, "The command should only run when confirmed")
+			if tt.input != "" {
+				assert.Contains(t, stderr, "Delete everything? [y/N]: ", "The confirmation should be asked")
+			} else {
+				assert.NotContains(t, stderr, "Delete everything?", "The confirmation should be skipped")
+			}
+		})
+	}
+}
+
+func TestRun_Confirmation_EnvironmentVariable(t *testing.T) {
+	t.Setenv("APP_ASSUME_YES", "true")
+	cfg := snek.NewConfig(snek.WithEnvironmentVariablePrefix("APP_"))
+	ran, _, err := runConfirmationTest(t, cfg, strings.NewReader(""), snek.WithConfirmation("Delete?"), []string{"delete"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, ran, "The environment variable should skip the confirmation")
+
+	t.Setenv("APP_ASSUME_YES", "maybe")
+	ran, _, err = runConfirmationTest(t, cfg, strings.NewReader(""), snek.WithConfirmation("Delete?"), []string{"delete"})
+	assert.ErrorIs(t, err, snek.ErrFlagEnvVarInvalid, "Run should return ErrFlagEnvVarInvalid for an invalid value")
+	assert.False(t, ran, "The command should not run")
+
+	t.Setenv("APP_ASSUME_YES", "true")
+	ran, _, err = runConfirmationTest(t, cfg, strings.NewReader("n\n"), snek.WithConfirmation("Delete?"),
+		[]string{"delete", "--yes=false"})
+	assert.ErrorIs(t, err, snek.ErrConfirmationDeclined, "The flag should take precedence over the environment variable")
+	assert.False(t, ran, "The command should not run")
+}
+
+func TestRun_Confirmation_EnvironmentVariableName(t *testing.T) {
+	t.Setenv("APP_FORCE", "true")
+	cfg := snek.NewConfig(
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithConfirmationEnvironmentVariableName("FORCE"),
+	)
+	ran, _, err := runConfirmationTest(t, cfg, strings.NewReader(""), snek.WithConfirmation("Delete?"), []string{"delete"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, ran, "The renamed environment variable should skip the confirmation")
+
+	cfg = snek.NewConfig(snek.WithConfirmationEnvironmentVariableName(""))
+	_, _, err = runConfirmationTest(t, cfg, strings.NewReader(""), snek.WithConfirmation("Delete?"), []string{"delete"})
+	assert.ErrorIs(t, err, snek.ErrConfirmationEnvironmentVariableNameEmpty,
+		"Run should return ErrConfirmationEnvironmentVariableNameEmpty for an empty environment variable name")
+}
+
+func TestRun_Confirmation_NonInteractive(t *testing.T) {
+	ran, _, err := runConfirmationTest(t, snek.NewConfig(), nonInteractiveInput(t), snek.WithConfirmation("Delete?"), []string{"delete"})
+	assert.ErrorIs(t, err, snek.ErrConfirmationRequired, "Run should return ErrConfirmationRequired without a terminal")
+	assert.False(t, ran, "The command should not run")
+
+	ran, _, err = runConfirmationTest(t, snek.NewConfig(snek.WithPrompt(true)), strings.NewReader("y\n"),
+		snek.WithConfirmation("Delete?"), []string{"delete", "--no-input"})
+	assert.ErrorIs(t, err, snek.ErrConfirmationRequired, "Run should return ErrConfirmationRequired with --no-input")
+	assert.False(t, ran, "The command should not run")
+
+	ran, _, err = runConfirmationTest(t, snek.NewConfig(), nonInteractiveInput(t), snek.WithConfirmation("Delete?"), []string{"delete", "--yes"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, ran, "The yes flag should skip the confirmation without a terminal")
+}
+
+func TestRun_TypedConfirmation(t *testing.T) {
+	resource := func(cmd *cobra.Command, args []string) string {
+		return "production"
+	}
+
+	ran, stderr, err := runConfirmationTest(t, snek.NewConfig(), strings.NewReader("production\n"),
+		snek.WithTypedConfirmation("This drops the database.", resource), []string{"delete"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, ran, "The command should run when the name is typed")
+	assert.Contains(t, stderr, "This drops the database.\nType \"production\" to confirm: ",
+		"The name to type should be asked for")
+
+	ran, _, err = runConfirmationTest(t, snek.NewConfig(), strings.NewReader("y\n"),
+		snek.WithTypedConfirmation("This drops the database.", resource), []string{"delete"})
+	assert.ErrorIs(t, err, snek.ErrConfirmationDeclined, "Run should return ErrConfirmationDeclined for a wrong name")
+	assert.False(t, ran, "The command should not run")
+}
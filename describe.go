@@ -0,0 +1,257 @@
+package snek
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CommandDescription is a machine-readable description of a command and its
+// descendants.
+type CommandDescription struct {
+	// Name is the name of the command.
+	Name string `json:"name"`
+
+	// Path is the full path of the command, starting with the root command.
+	Path string `json:"path"`
+
+	// Aliases is the aliases of the command.
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Short is the short description of the command.
+	Short string `json:"short,omitempty"`
+
+	// Long is the long description of the command.
+	Long string `json:"long,omitempty"`
+
+	// Example is the example of the command.
+	Example string `json:"example,omitempty"`
+
+	// Deprecated is the deprecated message of the command.
+	Deprecated string `json:"deprecated,omitempty"`
+
+	// Version is the version of the command.
+	Version string `json:"version,omitempty"`
+
+	// Group is the ID of the group the command is listed under.
+	Group string `json:"group,omitempty"`
+
+	// Hidden is whether the command is hidden from the help output.
+	Hidden bool `json:"hidden,omitempty"`
+
+	// Builtin is whether the command was added by snek or cobra rather than
+	// by the application, such as the help and completion commands.
+	Builtin bool `json:"builtin,omitempty"`
+
+	// Args is the description of the positional arguments of the command.
+	Args ArgsDescription `json:"args"`
+
+	// Flags is the descriptions of the flags defined on the command, sorted by
+	// name. Flags inherited from a parent command are described on the parent.
+	Flags []FlagDescription `json:"flags,omitempty"`
+
+	// Commands is the descriptions of the subcommands of the command, sorted by
+	// name.
+	Commands []CommandDescription `json:"commands,omitempty"`
+}
+
+// ArgsDescription is a machine-readable description of the positional
+// arguments of a command.
+type ArgsDescription struct {
+	// Use is the part of the usage line of the command following its name,
+	// such as "<name> [flags]".
+	Use string `json:"use,omitempty"`
+
+	// ValidArgs is the fixed list of valid positional arguments.
+	ValidArgs []string `json:"valid_args,omitempty"`
+
+	// Dynamic is whether the valid positional arguments are computed at
+	// runtime, such as with WithArgsCompletion.
+	Dynamic bool `json:"dynamic,omitempty"`
+}
+
+// FlagDescription is a machine-readable description of a flag.
+type FlagDescription struct {
+	// Name is the long name of the flag.
+	Name string `json:"name"`
+
+	// Shorthand is the short name of the flag.
+	Shorthand string `json:"shorthand,omitempty"`
+
+	// Type is the type of the flag, such as "string" or "int".
+	Type string `json:"type"`
+
+	// Default is the default value of the flag.
+	Default string `json:"default"`
+
+	// Usage is the help text of the flag.
+	Usage string `json:"usage,omitempty"`
+
+	// EnvironmentVariable is the name of the environment variable that
+	// overrides the default value of the flag.
+	EnvironmentVariable string `json:"env,omitempty"`
+
+	// Choices is the values accepted by an enum flag.
+	Choices []string `json:"choices,omitempty"`
+
+	// Persistent is whether the flag is inherited by the descendants of the
+	// command.
+	Persistent bool `json:"persistent,omitempty"`
+
+	// Required is whether the flag must be set.
+	Required bool `json:"required,omitempty"`
+
+	// Deprecated is the deprecated message of the flag.
+	Deprecated string `json:"deprecated,omitempty"`
+
+	// Hidden is whether the flag is hidden from the help output.
+	Hidden bool `json:"hidden,omitempty"`
+
+	// Builtin is whether the flag was added by snek or cobra rather than by
+	// the application, such as the log flags added by Run and the help flag.
+	Builtin bool `json:"builtin,omitempty"`
+}
+
+const (
+	// flagAnnotationBuiltin is the flag annotation marking a flag added by
+	// snek rather than by the application, such as the log flags added by Run.
+	flagAnnotationBuiltin = "snek_builtin"
+
+	// commandAnnotationBuiltin is the command annotation marking a command
+	// added by snek rather than by the application, such as the completion
+	// command added by Run.
+	commandAnnotationBuiltin = "snek_builtin"
+)
+
+// annotateBuiltin marks the flags with the specified names as added by snek.
+func annotateBuiltin(flags *pflag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if err := flags.SetAnnotation(name, flagAnnotationBuiltin, []string{"true"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isBuiltinFlag returns true if the flag was added by snek or cobra, such as
+// the help and version flags cobra adds when the command executes.
+func isBuiltinFlag(flag *pflag.Flag) bool {
+	return len(flag.Annotations[flagAnnotationBuiltin]) > 0 || len(flag.Annotations[cobra.FlagSetByCobraAnnotation]) > 0
+}
+
+// isBuiltinCommand returns true if the command was added by snek or cobra. The
+// help command cobra adds is the only runnable command that is neither hidden
+// nor deprecated and is still not available, and the default completion
+// command cobra adds is the `completion` command of the root command unless
+// it is disabled.
+func isBuiltinCommand(cmd *Command) bool {
+	if cmd.Annotations[commandAnnotationBuiltin] != "" {
+		return true
+	}
+
+	if cmd.HasParent() && cmd.Runnable() && !cmd.Hidden && cmd.Deprecated == "" && !cmd.IsAvailableCommand() {
+		return true
+	}
+
+	parent := cmd.Parent()
+	return parent != nil && !parent.HasParent() && cmd.Name() == "completion" &&
+		!parent.CompletionOptions.DisableDefaultCmd
+}
+
+// Describe returns a machine-readable description of the specified command and
+// all of its descendants, including their aliases, arguments, flags,
+// environment variables, deprecations and examples. The description can be
+// encoded as JSON to generate documentation or detect breaking changes. The
+// flags and commands added by snek and cobra, such as the log flags added by
+// Run, are described as builtin.
+func Describe(cmd *Command) CommandDescription {
+	desc := CommandDescription{
+		Name:       cmd.Name(),
+		Path:       cmd.CommandPath(),
+		Aliases:    cmd.Aliases,
+		Short:      cmd.Short,
+		Long:       cmd.Long,
+		Example:    cmd.Example,
+		Deprecated: cmd.Deprecated,
+		Version:    cmd.Version,
+		Group:      cmd.GroupID,
+		Hidden:     cmd.Hidden,
+		Builtin:    isBuiltinCommand(cmd),
+		Args: ArgsDescription{
+			ValidArgs: cmd.ValidArgs,
+			Dynamic:   cmd.ValidArgsFunction != nil,
+		},
+	}
+
+	if _, use, ok := strings.Cut(cmd.Use, " "); ok {
+		desc.Args.Use = strings.TrimSpace(use)
+	}
+
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if isExtensionsFlag(flag) {
+			return
+		}
+		desc.Flags = append(desc.Flags, describeFlag(cmd, flag))
+	})
+
+	for _, child := range cmd.Commands() {
+		desc.Commands = append(desc.Commands, Describe(child))
+	}
+
+	return desc
+}
+
+// describeFlag returns the description of the specified flag of the command.
+func describeFlag(cmd *Command, flag *pflag.Flag) FlagDescription {
+	desc := FlagDescription{
+		Name:       flag.Name,
+		Shorthand:  flag.Shorthand,
+		Type:       flag.Value.Type(),
+		Default:    flag.DefValue,
+		Usage:      flag.Usage,
+		Choices:    flag.Annotations[FlagAnnotationEnum],
+		Persistent: cmd.PersistentFlags().Lookup(flag.Name) != nil,
+		Deprecated: flag.Deprecated,
+		Hidden:     flag.Hidden,
+		Builtin:    isBuiltinFlag(flag),
+	}
+
+	if envVars := flag.Annotations[FlagAnnotationEnvironmentVariable]; len(envVars) > 0 {
+		desc.EnvironmentVariable = envVars[0]
+	}
+
+	if required := flag.Annotations[cobra.BashCompOneRequiredFlag]; len(required) > 0 {
+		desc.Required = required[0] == "true"
+	}
+
+	return desc
+}
+
+// NewDescribeCommand creates a hidden `describe-cli` command that writes the
+// description of the root of the command tree it is added to as JSON to the
+// output of the command.
+//
+// The command is usually added to the root command with
+// WithSubCommandGenerator.
+func NewDescribeCommand() (*Command, error) {
+	return NewCommand(
+		WithUse("describe-cli"),
+		WithShort("Describe the commands and flags of this application as JSON"),
+		WithRunE(func(cmd *Command, args []string) error {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(Describe(cmd.Root()))
+		}),
+		func(cmd *Command) error {
+			cmd.Args = cobra.NoArgs
+			cmd.Hidden = true
+
+			// Skip any persistent hooks of the root command, such as logging
+			// setup, so nothing but the description is written to the output.
+			cmd.PersistentPreRunE = func(*Command, []string) error { return nil }
+			return nil
+		},
+	)
+}
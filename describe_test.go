@@ -0,0 +1,157 @@
+package snek_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+// describeTestInitializers returns the initializers of a root command with a
+// persistent flag and two subcommands, followed by the initializers provided.
+func describeTestInitializers(t *testing.T, initializers ...snek.Initializer) []snek.Initializer {
+	t.Helper()
+	var (
+		verbose bool
+		name    string
+		format  string
+	)
+
+	get, err := snek.NewCommand(
+		snek.WithUse("get <name>"),
+		snek.WithAliases("g"),
+		snek.WithShort("Get a resource"),
+		snek.WithExample("app get foo"),
+		snek.WithValidArgs("foo", "bar"),
+		snek.WithFlag(
+			snek.WithStringVarPE(&name, "name", "n", "TEST_NAME", "default", "The name"),
+			snek.WithEnumVar(&format, "format", "json", []string{"json", "table"}, "The format"),
+		),
+		snek.WithRequiredFlag("name"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	old, err := snek.NewCommand(
+		snek.WithUse("old"),
+		snek.WithDeprecated("use get instead"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	return append([]snek.Initializer{
+		snek.WithUse("app"),
+		snek.WithVersion("1.2.3"),
+		snek.WithPersistentFlag(snek.WithBoolVarP(&verbose, "verbose", "v", false, "Verbose output")),
+		snek.WithSubCommand(get, old),
+	}, initializers...)
+}
+
+func newDescribeTestCommand(t *testing.T, initializers ...snek.Initializer) *cobra.Command {
+	t.Helper()
+	root, err := snek.NewCommand(describeTestInitializers(t, initializers...)...)
+	require.NoError(t, err, "NewCommand should not return an error")
+	return root
+}
+
+func TestDescribe(t *testing.T) {
+	desc := snek.Describe(newDescribeTestCommand(t))
+
+	assert.Equal(t, "app", desc.Name, "The name should be described")
+	assert.Equal(t, "app", desc.Path, "The path should be described")
+	assert.Equal(t, "1.2.3", desc.Version, "The version should be described")
+	require.Len(t, desc.Flags, 1, "The local flags should be described")
+	assert.Equal(t, snek.FlagDescription{
+		Name:       "verbose",
+		Shorthand:  "v",
+		Type:       "bool",
+		Default:    "false",
+		Usage:      "Verbose output",
+		Persistent: true,
+	}, desc.Flags[0], "The persistent flag should be described")
+
+	require.Len(t, desc.Commands, 2, "The subcommands should be described")
+	get := desc.Commands[0]
+	assert.Equal(t, "get", get.Name, "The subcommands should be sorted by name")
+	assert.Equal(t, "app get", get.Path, "The path should include the parent")
+	assert.Equal(t, []string{"g"}, get.Aliases, "The aliases should be described")
+	assert.Equal(t, "app get foo", get.Example, "The example should be described")
+	assert.Equal(t, snek.ArgsDescription{Use: "<name>", ValidArgs: []string{"foo", "bar"}}, get.Args,
+		"The arguments should be described")
+	require.Len(t, get.Flags, 2, "The inherited flags should not be described")
+	assert.Equal(t, snek.FlagDescription{
+		Name:    "format",
+		Type:    "string",
+		Default: "json",
+		Usage:   "The format",
+		Choices: []string{"json", "table"},
+	}, get.Flags[0], "The enum flag should be described")
+	assert.Equal(t, snek.FlagDescription{
+		Name:                "name",
+		Shorthand:           "n",
+		Type:                "string",
+		Default:             "default",
+		Usage:               "The name",
+		EnvironmentVariable: "TEST_NAME",
+		Required:            true,
+	}, get.Flags[1], "The required flag should be described")
+
+	assert.Equal(t, "use get instead", desc.Commands[1].Deprecated, "The deprecation should be described")
+}
+
+func TestDescribe_JSON(t *testing.T) {
+	data, err := json.Marshal(snek.Describe(newDescribeTestCommand(t)))
+	require.NoError(t, err, "The description should be encoded as JSON")
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded), "The description should be valid JSON")
+	assert.Equal(t, "app", decoded["name"], "The name should be encoded")
+	assert.NotContains(t, decoded, "deprecated", "Empty values should be omitted")
+}
+
+func TestNewDescribeCommand(t *testing.T) {
+	var out bytes.Buffer
+	err := snek.Run([]string{"describe-cli"}, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithUse("app"),
+		snek.WithSubCommandGenerator(snek.NewDescribeCommand),
+		func(cmd *cobra.Command) error {
+			cmd.SetOut(&out)
+			return nil
+		},
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	var desc snek.CommandDescription
+	require.NoError(t, json.Unmarshal(out.Bytes(), &desc), "The output should be the JSON description")
+	assert.Equal(t, "app", desc.Name, "The root command should be described")
+	var describe *snek.CommandDescription
+	for i := range desc.Commands {
+		if desc.Commands[i].Name == "describe-cli" {
+			describe = &desc.Commands[i]
+		}
+	}
+	require.NotNil(t, describe, "The describe command should be described")
+	assert.True(t, describe.Hidden, "The describe command should be hidden")
+
+	var names []string
+	for _, flag := range desc.Flags {
+		names = append(names, flag.Name)
+		if flag.Name == "log-level" {
+			assert.True(t, flag.Builtin, "The flags added by Run should be builtin")
+		}
+	}
+	assert.Contains(t, names, "log-level", "The log flags added by Run should be described")
+	assert.False(t, describe.Builtin, "The describe command should not be builtin")
+	for _, flag := range describe.Flags {
+		assert.True(t, flag.Builtin, "The help flag added by cobra should be builtin")
+	}
+	for _, child := range desc.Commands {
+		if child.Name == "help" || child.Name == "completion" {
+			assert.True(t, child.Builtin, "The commands added by cobra should be builtin")
+		}
+	}
+}
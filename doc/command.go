@@ -0,0 +1,42 @@
+package doc
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ronelliott/snek"
+)
+
+// NewCommand creates a hidden `gen-docs` command that writes the documentation
+// for the root of the command tree it is added to, which is executed with the
+// config. The format and output directory are set with the `--format` and
+// `--dir` flags, and the directory is created if it does not exist.
+//
+// The command is usually added to the root command with
+// snek.WithSubCommandGenerator, with the Config passed to snek.Run.
+func NewCommand(cfg *snek.Config) (*cobra.Command, error) {
+	format := FormatMarkdown
+	dir := "docs"
+	return snek.NewCommand(
+		snek.WithUse("gen-docs"),
+		snek.WithShort("Generate the documentation for this application"),
+		snek.WithFlag(
+			snek.WithEnumVar(&format, "format", format, []string{FormatMan, FormatMarkdown, FormatReST},
+				"The format of the documentation. Valid values are `man`, `markdown`, and `rest`."),
+			snek.WithStringVar(&dir, "dir", dir, "The directory to write the documentation to."),
+		),
+		snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+
+			return GenTree(cmd.Root(), cfg, format, dir)
+		}),
+		func(cmd *cobra.Command) error {
+			cmd.Args = cobra.NoArgs
+			cmd.Hidden = true
+			return cmd.MarkFlagDirname("dir")
+		},
+	)
+}
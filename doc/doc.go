@@ -0,0 +1,229 @@
+// Package doc generates man pages, Markdown and reStructuredText documentation
+// for a command tree created with snek. The generated documentation includes
+// an environment section listing every environment variable known to snek for
+// each command, as returned by snek.EnvironmentVariables for the Config the
+// command tree is executed with, so the documentation is complete without
+// executing the command tree with snek.Run.
+package doc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	cobradoc "github.com/spf13/cobra/doc"
+
+	"github.com/ronelliott/snek"
+)
+
+const (
+	// FormatMan is the format used to generate man pages.
+	FormatMan = "man"
+
+	// FormatMarkdown is the format used to generate Markdown documentation.
+	FormatMarkdown = "markdown"
+
+	// FormatReST is the format used to generate reStructuredText documentation.
+	FormatReST = "rest"
+)
+
+// GenManHeader is the header used when generating man pages.
+type GenManHeader = cobradoc.GenManHeader
+
+// EnvironmentVariable is an environment variable read when a command is
+// executed by snek.Run.
+type EnvironmentVariable = snek.EnvironmentVariable
+
+// EnvironmentVariables returns the environment variables read when the command
+// is executed by snek.Run with the config, sorted by name. If cfg is nil, then
+// the default Config is used.
+func EnvironmentVariables(cmd *cobra.Command, cfg *snek.Config) []EnvironmentVariable {
+	return snek.EnvironmentVariables(cmd, cfg)
+}
+
+// GenMarkdown writes the Markdown documentation for the command executed with
+// the config to w.
+func GenMarkdown(cmd *cobra.Command, cfg *snek.Config, w io.Writer) error {
+	var buf bytes.Buffer
+	if err := cobradoc.GenMarkdownCustom(cmd, &buf, func(s string) string { return s }); err != nil {
+		return err
+	}
+
+	var section strings.Builder
+	if vars := EnvironmentVariables(cmd, cfg); len(vars) > 0 {
+		section.WriteString("### Environment\n\n")
+		for _, envVar := range vars {
+			fmt.Fprintf(&section, "* `%s` - %s", envVar.Name, envVar.Usage)
+			if envVar.Flag != "" {
+				fmt.Fprintf(&section, " (`--%s`)", envVar.Flag)
+			}
+			section.WriteString("\n")
+		}
+		section.WriteString("\n")
+	}
+
+	return writeWithSection(w, buf.String(), section.String(), "### SEE ALSO", "###### Auto generated")
+}
+
+// GenMarkdownTree writes the Markdown documentation for the command and all
+// of its available descendants executed with the config to the specified
+// directory, one file per command.
+func GenMarkdownTree(cmd *cobra.Command, cfg *snek.Config, dir string) error {
+	return genTree(cmd, dir, func(cmd *cobra.Command) string {
+		return strings.ReplaceAll(cmd.CommandPath(), " ", "_") + ".md"
+	}, func(cmd *cobra.Command, w io.Writer) error {
+		return GenMarkdown(cmd, cfg, w)
+	})
+}
+
+// GenMan writes the man page for the command executed with the config to w.
+// If header is nil, then a default header is used.
+func GenMan(cmd *cobra.Command, cfg *snek.Config, header *GenManHeader, w io.Writer) error {
+	if header == nil {
+		header = &GenManHeader{}
+	}
+
+	var buf bytes.Buffer
+	if err := cobradoc.GenMan(cmd, header, &buf); err != nil {
+		return err
+	}
+
+	var section strings.Builder
+	if vars := EnvironmentVariables(cmd, cfg); len(vars) > 0 {
+		section.WriteString(".SH ENVIRONMENT\n")
+		for _, envVar := range vars {
+			fmt.Fprintf(&section, ".PP\n\\fB%s\\fP\n\t%s", envVar.Name, envVar.Usage)
+			if envVar.Flag != "" {
+				fmt.Fprintf(&section, " (\\fB--%s\\fP)", envVar.Flag)
+			}
+			section.WriteString("\n")
+		}
+		section.WriteString("\n")
+	}
+
+	return writeWithSection(w, buf.String(), section.String(), ".SH SEE ALSO", ".SH HISTORY")
+}
+
+// GenManTree writes the man pages for the command and all of its available
+// descendants executed with the config to the specified directory, one file
+// per command. If header is nil, then a default header is used.
+func GenManTree(cmd *cobra.Command, cfg *snek.Config, header *GenManHeader, dir string) error {
+	if header == nil {
+		header = &GenManHeader{}
+	}
+
+	section := header.Section
+	if section == "" {
+		section = "1"
+	}
+
+	return genTree(cmd, dir, func(cmd *cobra.Command) string {
+		return strings.ReplaceAll(cmd.CommandPath(), " ", "-") + "." + section
+	}, func(cmd *cobra.Command, w io.Writer) error {
+		// Copy the header so the title generated for each command is not
+		// reused for the next command.
+		commandHeader := *header
+		return GenMan(cmd, cfg, &commandHeader, w)
+	})
+}
+
+// GenReST writes the reStructuredText documentation for the command executed
+// with the config to w.
+func GenReST(cmd *cobra.Command, cfg *snek.Config, w io.Writer) error {
+	var buf bytes.Buffer
+	linkHandler := func(name, ref string) string {
+		return fmt.Sprintf(":ref:`%s <%s>`", name, ref)
+	}
+	if err := cobradoc.GenReSTCustom(cmd, &buf, linkHandler); err != nil {
+		return err
+	}
+
+	var section strings.Builder
+	if vars := EnvironmentVariables(cmd, cfg); len(vars) > 0 {
+		section.WriteString("Environment\n~~~~~~~~~~~\n\n")
+		for _, envVar := range vars {
+			fmt.Fprintf(&section, "``%s``\n    %s", envVar.Name, envVar.Usage)
+			if envVar.Flag != "" {
+				fmt.Fprintf(&section, " (``--%s``)", envVar.Flag)
+			}
+			section.WriteString("\n\n")
+		}
+	}
+
+	return writeWithSection(w, buf.String(), section.String(), "SEE ALSO\n", "*Auto generated")
+}
+
+// GenReSTTree writes the reStructuredText documentation for the command and
+// all of its available descendants executed with the config to the specified
+// directory, one file per command.
+func GenReSTTree(cmd *cobra.Command, cfg *snek.Config, dir string) error {
+	return genTree(cmd, dir, func(cmd *cobra.Command) string {
+		return strings.ReplaceAll(cmd.CommandPath(), " ", "_") + ".rst"
+	}, func(cmd *cobra.Command, w io.Writer) error {
+		return GenReST(cmd, cfg, w)
+	})
+}
+
+// GenTree writes the documentation for the command and all of its available
+// descendants executed with the config to the specified directory in the
+// specified format. If the format is not one of FormatMan, FormatMarkdown or
+// FormatReST, then an error wrapping ErrFormatInvalid is returned.
+func GenTree(cmd *cobra.Command, cfg *snek.Config, format, dir string) error {
+	switch format {
+	case FormatMan:
+		return GenManTree(cmd, cfg, nil, dir)
+	case FormatMarkdown:
+		return GenMarkdownTree(cmd, cfg, dir)
+	case FormatReST:
+		return GenReSTTree(cmd, cfg, dir)
+	default:
+		return fmt.Errorf("%w: %s", ErrFormatInvalid, format)
+	}
+}
+
+// genTree writes the documentation for the command and all of its available
+// descendants to the specified directory using the file name and generator
+// provided.
+func genTree(
+	cmd *cobra.Command,
+	dir string,
+	filename func(*cobra.Command) string,
+	generate func(*cobra.Command, io.Writer) error,
+) error {
+	for _, child := range cmd.Commands() {
+		if !child.IsAvailableCommand() || child.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+
+		if err := genTree(child, dir, filename, generate); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(filepath.Join(dir, filename(cmd)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return generate(cmd, file)
+}
+
+// writeWithSection writes the document to w with the section inserted before
+// the first of the markers found in the document. If none of the markers are
+// found, then the section is appended to the document.
+func writeWithSection(w io.Writer, document, section string, markers ...string) error {
+	index := len(document)
+	for _, marker := range markers {
+		if i := strings.Index(document, marker); i >= 0 && i < index {
+			index = i
+		}
+	}
+
+	_, err := io.WriteString(w, document[:index]+section+document[index:])
+	return err
+}
@@ -0,0 +1,127 @@
+package doc_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+	"github.com/ronelliott/snek/doc"
+)
+
+func newTestCommand(t *testing.T) *cobra.Command {
+	t.Helper()
+	var port int
+	var verbose bool
+	serve, err := snek.NewCommand(
+		snek.WithUse("serve"),
+		snek.WithShort("Serve the app"),
+		snek.WithFlag(snek.WithIntVarE(&port, "port", "APP_PORT", 8080, "The port to bind to")),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	_, err = snek.NewCommand(
+		snek.WithUse("app"),
+		snek.WithShort("The app"),
+		snek.WithPersistentFlag(snek.WithBoolVarE(&verbose, "verbose", "APP_VERBOSE", false, "Enable verbose output")),
+		snek.WithSubCommand(serve),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	return serve
+}
+
+func TestEnvironmentVariables(t *testing.T) {
+	cmd := newTestCommand(t)
+	names := []string{}
+	for _, envVar := range doc.EnvironmentVariables(cmd, snek.NewConfig(snek.WithEnvironmentVariablePrefix("APP_"))) {
+		names = append(names, envVar.Name)
+	}
+	assert.Subset(t, names, []string{"APP_LOG_CALLER", "APP_LOG_FORMAT", "APP_LOG_LEVEL", "APP_PORT", "APP_VERBOSE"},
+		"The environment variables of the flags and of Run should be returned without executing Run")
+	assert.True(t, slices.IsSorted(names), "The environment variables should be sorted by name")
+}
+
+func TestGenMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, doc.GenMarkdown(newTestCommand(t), nil, &buf), "GenMarkdown should not return an error")
+
+	out := buf.String()
+	assert.Contains(t, out, "### Environment\n\n* `APP_PORT` - The port to bind to (`--port`)\n",
+		"The environment section should list the environment variables")
+	assert.Contains(t, out, "* `LOG_CALLER` - Add a `caller` field", "Environment variables without a flag should be listed")
+	assert.Less(t, bytes.Index(buf.Bytes(), []byte("### Environment")), bytes.Index(buf.Bytes(), []byte("### SEE ALSO")),
+		"The environment section should be written before the see also section")
+}
+
+func TestGenMan(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, doc.GenMan(newTestCommand(t), nil, nil, &buf), "GenMan should not return an error")
+
+	out := buf.String()
+	assert.Contains(t, out, ".SH ENVIRONMENT\n.PP\n\\fBAPP_PORT\\fP\n",
+		"The environment section should list the environment variables")
+	assert.Less(t, bytes.Index(buf.Bytes(), []byte(".SH ENVIRONMENT")), bytes.Index(buf.Bytes(), []byte(".SH SEE ALSO")),
+		"The environment section should be written before the see also section")
+}
+
+func TestGenReST(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, doc.GenReST(newTestCommand(t), nil, &buf), "GenReST should not return an error")
+
+	out := buf.String()
+	assert.Contains(t, out, "Environment\n~~~~~~~~~~~\n\n``APP_PORT``\n    The port to bind to (``--port``)\n",
+		"The environment section should list the environment variables")
+}
+
+func TestGenTree(t *testing.T) {
+	tests := map[string][]string{
+		doc.FormatMan:      {"app.1", "app-serve.1"},
+		doc.FormatMarkdown: {"app.md", "app_serve.md"},
+		doc.FormatReST:     {"app.rst", "app_serve.rst"},
+	}
+
+	for format, files := range tests {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, doc.GenTree(newTestCommand(t).Root(), nil, format, dir), "GenTree should not return an error")
+			for _, file := range files {
+				assert.FileExists(t, filepath.Join(dir, file), "GenTree should write a file for every command")
+			}
+		})
+	}
+}
+
+func TestGenTree_InvalidFormat(t *testing.T) {
+	err := doc.GenTree(newTestCommand(t), nil, "pdf", t.TempDir())
+	assert.ErrorIs(t, err, doc.ErrFormatInvalid, "GenTree should return an error for an invalid format")
+}
+
+func TestNewCommand(t *testing.T) {
+	dir := t.TempDir()
+	cfg := snek.NewConfig(
+		snek.WithLogOutput(io.Discard),
+		snek.WithEnvironmentVariablePrefix("APP_"),
+	)
+	err := snek.Run([]string{"gen-docs", "--dir", dir}, cfg,
+		snek.WithUse("app"),
+		snek.WithSubCommandGenerator(func() (*cobra.Command, error) {
+			return doc.NewCommand(cfg)
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	out, err := os.ReadFile(filepath.Join(dir, "app.md"))
+	require.NoError(t, err, "The root command documentation should be written")
+	assert.Contains(t, string(out), "`APP_LOG_FORMAT`", "The log format environment variable should be listed with its prefix")
+	assert.Contains(t, string(out), "`APP_LOG_LEVEL`", "The log level environment variable should be listed with its prefix")
+	assert.Contains(t, string(out), "`APP_LOG_PID`", "The log field environment variables should be listed with their prefix")
+	assert.NotContains(t, string(out), "gen-docs", "The hidden command should not be documented")
+}
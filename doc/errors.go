@@ -0,0 +1,8 @@
+package doc
+
+import "errors"
+
+var (
+	// ErrFormatInvalid is returned when the documentation format is invalid.
+	ErrFormatInvalid = errors.New("invalid documentation format")
+)
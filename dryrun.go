@@ -0,0 +1,26 @@
+package snek
+
+import "context"
+
+// dryRunKey is the context key of the dry run value set by ContextWithDryRun.
+type dryRunKey struct{}
+
+// ContextWithDryRun returns a copy of the context with the dry run value set,
+// which is returned by IsDryRun. Run sets the value on the context of the
+// executing command when the `--dry-run` flag added with WithDryRunFlag is set,
+// so this is only needed to call handlers directly, such as in tests.
+func ContextWithDryRun(ctx context.Context, dryRun bool) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, dryRun)
+}
+
+// IsDryRun returns true if the command executing with the context should only
+// report what it would do, without making any changes. This is the case when
+// the `--dry-run` flag added by Run with WithDryRunFlag is set.
+func IsDryRun(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
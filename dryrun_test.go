@@ -0,0 +1,107 @@
+package snek_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func runDryRunTest(t *testing.T, cfg *snek.Config, args []string) (bool, string, error) {
+	t.Helper()
+	var dryRun bool
+	var logs bytes.Buffer
+	snek.WithLogOutput(&logs)(cfg)
+	snek.WithDefaultLogFormat("json")(cfg)
+	err := snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+			dryRun = snek.IsDryRun(cmd.Context())
+			snek.Logger(cmd).Info().Msg("Deleting.")
+			return nil
+		}),
+	)
+	return dryRun, logs.String(), err
+}
+
+func TestIsDryRun(t *testing.T) {
+	assert.False(t, snek.IsDryRun(context.Background()), "IsDryRun should be false by default")
+	assert.False(t, snek.IsDryRun(nil), "IsDryRun should be false for a nil context")
+	assert.True(t, snek.IsDryRun(snek.ContextWithDryRun(context.Background(), true)), "IsDryRun should be true when set")
+	assert.False(t, snek.IsDryRun(snek.ContextWithDryRun(context.Background(), false)), "IsDryRun should be false when unset")
+}
+
+func TestWithDryRunFlag(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.DryRunFlag, "DryRunFlag should be false")
+	cfg = snek.NewConfig(snek.WithDryRunFlag(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.DryRunFlag, "DryRunFlag should be true")
+}
+
+func TestWithDryRunEnvironmentVariableName(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "DRY_RUN", cfg.DryRunEnvironmentVariableName,
+		"The default dry run environment variable name should be DRY_RUN")
+	cfg = snek.NewConfig(snek.WithDryRunEnvironmentVariableName("REHEARSAL"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "REHEARSAL", cfg.DryRunEnvironmentVariableName, "DryRunEnvironmentVariableName should be REHEARSAL")
+}
+
+func TestRun_DryRun(t *testing.T) {
+	dryRun, logs, err := runDryRunTest(t, snek.NewConfig(snek.WithDryRunFlag(true)), []string{"--dry-run"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, dryRun, "IsDryRun should be true with --dry-run")
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	require.Len(t, lines, 2, "Two lines should be logged")
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), "The log line should be JSON")
+		assert.Equal(t, true, entry["dry_run"], "Every log line should be tagged with dry_run")
+	}
+
+	dryRun, logs, err = runDryRunTest(t, snek.NewConfig(snek.WithDryRunFlag(true)), []string{})
+	require.NoError(t, err, "Run should not return an error")
+	assert.False(t, dryRun, "IsDryRun should be false without --dry-run")
+	assert.NotContains(t, logs, "dry_run", "Logs should not be tagged with dry_run")
+}
+
+func TestRun_DryRun_EnvironmentVariable(t *testing.T) {
+	t.Setenv("APP_DRY_RUN", "true")
+	dryRun, _, err := runDryRunTest(t,
+		snek.NewConfig(snek.WithDryRunFlag(true), snek.WithEnvironmentVariablePrefix("APP_")), []string{})
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, dryRun, "IsDryRun should be true with the environment variable")
+
+	_, _, err = runDryRunTest(t, snek.NewConfig(snek.WithEnvironmentVariablePrefix("APP_")), []string{})
+	require.NoError(t, err, "Run should not return an error")
+
+	_, _, err = runDryRunTest(t,
+		snek.NewConfig(snek.WithEnvironmentVariablePrefix("APP_")), []string{"--dry-run"})
+	assert.Error(t, err, "The dry run flag should not be added without WithDryRunFlag")
+}
+
+func TestRun_DryRun_EnvironmentVariableName(t *testing.T) {
+	t.Setenv("APP_REHEARSAL", "true")
+	dryRun, _, err := runDryRunTest(t, snek.NewConfig(
+		snek.WithDryRunFlag(true),
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithDryRunEnvironmentVariableName("REHEARSAL"),
+	), []string{})
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, dryRun, "IsDryRun should be true with the renamed environment variable")
+
+	_, _, err = runDryRunTest(t,
+		snek.NewConfig(snek.WithDryRunFlag(true), snek.WithDryRunEnvironmentVariableName("")), []string{})
+	assert.ErrorIs(t, err, snek.ErrDryRunEnvironmentVariableNameEmpty,
+		"Run should return ErrDryRunEnvironmentVariableNameEmpty for an empty environment variable name")
+}
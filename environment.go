@@ -0,0 +1,131 @@
+package snek
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// EnvironmentVariable is an environment variable read when a command is
+// executed by Run.
+type EnvironmentVariable struct {
+	// Name is the name of the environment variable, including any prefix.
+	Name string
+
+	// Flag is the long name of the flag the environment variable overrides, or
+	// an empty string if the environment variable has no flag.
+	Flag string
+
+	// Usage is the help text of the environment variable, which is the help
+	// text of its flag when it has one.
+	Usage string
+}
+
+// EnvironmentVariables returns the environment variables read when the command
+// is executed by Run with the config, sorted by name. These are the variables
+// of the local and inherited flags of the command, such as those added with
+// the E family of flag initializers, the variables of the flags added to the
+// root command by Run, the variable that skips the confirmation of the command,
+// and the variables that enable the log fields. If cfg is nil, then the default
+// Config is used, as with Run.
+//
+// The flags added by Run do not need to exist, so the environment variables of
+// a command tree can be listed, such as for documentation, without executing
+// it.
+func EnvironmentVariables(cmd *Command, cfg *Config) []EnvironmentVariable {
+	if cfg == nil {
+		cfg = NewConfig()
+	}
+
+	prefix := cfg.EnvironmentVariablePrefix
+	vars := []EnvironmentVariable{
+		{
+			Name:  prefix + cfg.LogFormatEnvironmentVariableName,
+			Flag:  cfg.LogFormatCommandLineVariableLongName,
+			Usage: cfg.LogFormatCommandLineVariableHelp,
+		},
+		{
+			Name:  prefix + cfg.LogLevelEnvironmentVariableName,
+			Flag:  cfg.LogLevelCommandLineVariableLongName,
+			Usage: cfg.LogLevelCommandLineVariableHelp,
+		},
+	}
+
+	logFile := EnvironmentVariable{
+		Name:  prefix + cfg.LogFileEnvironmentVariableName,
+		Usage: cfg.LogFileCommandLineVariableHelp,
+	}
+	if cfg.LogFileFlag {
+		logFile.Flag = cfg.LogFileCommandLineVariableLongName
+	}
+	vars = append(vars, logFile)
+
+	if cfg.LogSamplingFlag {
+		vars = append(vars, EnvironmentVariable{
+			Name:  prefix + cfg.LogSamplingEnvironmentVariableName,
+			Flag:  cfg.LogSamplingCommandLineVariableLongName,
+			Usage: cfg.LogSamplingCommandLineVariableHelp,
+		})
+	}
+
+	for _, field := range optionalLogFields {
+		vars = append(vars, EnvironmentVariable{
+			Name:  prefix + field.environmentVariableName(cfg),
+			Usage: field.usage,
+		})
+	}
+
+	if cfg.OutputFlag {
+		vars = append(vars, EnvironmentVariable{
+			Name:  prefix + cfg.OutputEnvironmentVariableName,
+			Flag:  "output",
+			Usage: outputFlagUsage,
+		})
+	}
+
+	if cfg.Prompt {
+		vars = append(vars, EnvironmentVariable{
+			Name:  prefix + cfg.NoInputEnvironmentVariableName,
+			Flag:  "no-input",
+			Usage: noInputFlagUsage,
+		})
+	}
+
+	if cfg.DryRunFlag {
+		vars = append(vars, EnvironmentVariable{
+			Name:  prefix + cfg.DryRunEnvironmentVariableName,
+			Flag:  "dry-run",
+			Usage: dryRunFlagUsage,
+		})
+	}
+
+	if ext := lookupExtensions(cmd); ext != nil && ext.confirmation != nil {
+		if flag := cmd.Flags().Lookup("yes"); flag != nil {
+			vars = append(vars, EnvironmentVariable{
+				Name:  prefix + cfg.ConfirmationEnvironmentVariableName,
+				Flag:  flag.Name,
+				Usage: flag.Usage,
+			})
+		}
+	}
+
+	collect := func(flag *pflag.Flag) {
+		for _, name := range flag.Annotations[FlagAnnotationEnvironmentVariable] {
+			vars = append(vars, EnvironmentVariable{Name: name, Flag: flag.Name, Usage: flag.Usage})
+		}
+	}
+
+	cmd.LocalFlags().VisitAll(collect)
+	cmd.InheritedFlags().VisitAll(collect)
+
+	// The flags added by Run are annotated with the environment variables
+	// listed above once the command has been executed by Run, so the first
+	// of each name is kept.
+	slices.SortStableFunc(vars, func(a, b EnvironmentVariable) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return slices.CompactFunc(vars, func(a, b EnvironmentVariable) bool {
+		return a.Name == b.Name
+	})
+}
@@ -0,0 +1,60 @@
+package snek_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func TestEnvironmentVariables(t *testing.T) {
+	var port int
+	cmd, err := snek.NewCommand(
+		snek.WithUse("delete"),
+		snek.WithFlag(snek.WithIntVarE(&port, "port", "APP_PORT", 8080, "The port to bind to.")),
+		snek.WithConfirmation("Delete?"),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	cfg := snek.NewConfig(
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithLogOutput(io.Discard),
+		snek.WithLogFileFlag(true),
+		snek.WithLogPIDEnvironmentVariableName("PID"),
+		snek.WithPrompt(true),
+	)
+	vars := snek.EnvironmentVariables(cmd, cfg)
+	names := make([]string, 0, len(vars))
+	for _, envVar := range vars {
+		names = append(names, envVar.Name)
+	}
+	assert.Equal(t, []string{
+		"APP_ASSUME_YES",
+		"APP_LOG_CALLER",
+		"APP_LOG_COMMAND",
+		"APP_LOG_FILE",
+		"APP_LOG_FORMAT",
+		"APP_LOG_HOSTNAME",
+		"APP_LOG_LEVEL",
+		"APP_LOG_VERSION",
+		"APP_NO_INPUT",
+		"APP_PID",
+		"APP_PORT",
+	}, names, "The environment variables read by Run should be returned without executing Run")
+	assert.Contains(t, vars, snek.EnvironmentVariable{Name: "APP_LOG_FILE", Flag: "log-file", Usage: cfg.LogFileCommandLineVariableHelp},
+		"The environment variable of a flag added by Run should name the flag")
+	assert.Contains(t, vars, snek.EnvironmentVariable{Name: "APP_PORT", Flag: "port", Usage: "The port to bind to."},
+		"The environment variable of a flag should name the flag")
+	assert.Empty(t, vars[1].Flag, "The environment variable of a log field should not name a flag")
+	assert.NotEmpty(t, vars[1].Usage, "The environment variable of a log field should have a usage")
+
+	err = snek.Run([]string{"delete", "--yes"}, cfg, snek.WithUse("app"), snek.WithSubCommand(cmd))
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, vars, snek.EnvironmentVariables(cmd, cfg),
+		"The environment variables should be the same once the command has been executed by Run")
+}
@@ -17,6 +17,18 @@ var (
 	// environment variable name is empty.
 	ErrLogFormatEnvironmentVariableNameEmpty = errors.New("log format environment variable name is empty")
 
+	// ErrLogFileCommandLineVariableNameEmpty is returned when the log file
+	// command line variable name is empty.
+	ErrLogFileCommandLineVariableNameEmpty = errors.New("log file command line variable name is empty")
+
+	// ErrLogFileEnvironmentVariableNameEmpty is returned when the log file
+	// environment variable name is empty.
+	ErrLogFileEnvironmentVariableNameEmpty = errors.New("log file environment variable name is empty")
+
+	// ErrLogFileRotationInvalid is returned when the log file rotation has a
+	// negative value.
+	ErrLogFileRotationInvalid = errors.New("log file rotation is invalid")
+
 	// ErrLogLevelCommandLineVariableNameEmpty is returned when both the long and
 	// short names of the log level command line variable are empty.
 	ErrLogLevelCommandLineVariableNameEmpty = errors.New("log level command line variable name is empty")
@@ -25,10 +37,128 @@ var (
 	// environment variable name is empty.
 	ErrLogLevelEnvironmentVariableNameEmpty = errors.New("log level environment variable name is empty")
 
+	// ErrLogDeduplicationInvalid is returned when the log deduplication window
+	// is negative.
+	ErrLogDeduplicationInvalid = errors.New("log deduplication is invalid")
+
+	// ErrLogFieldEnvironmentVariableInvalid is returned when the environment
+	// variable that enables a log field cannot be parsed as a bool.
+	ErrLogFieldEnvironmentVariableInvalid = errors.New("log field environment variable value is invalid")
+
+	// ErrLogFieldEnvironmentVariableNameEmpty is returned when the name of the
+	// environment variable that enables a log field is empty.
+	ErrLogFieldEnvironmentVariableNameEmpty = errors.New("log field environment variable name is empty")
+
+	// ErrLogSamplingInvalid is returned when the log sampling cannot be parsed,
+	// samples a level that cannot be sampled, or does not write any logs.
+	ErrLogSamplingInvalid = errors.New("log sampling is invalid")
+
+	// ErrDryRunEnvironmentVariableNameEmpty is returned when the dry run
+	// environment variable name is empty.
+	ErrDryRunEnvironmentVariableNameEmpty = errors.New("dry run environment variable name is empty")
+
+	// ErrLogSamplingCommandLineVariableNameEmpty is returned when the log
+	// sampling command line variable name is empty.
+	ErrLogSamplingCommandLineVariableNameEmpty = errors.New("log sampling command line variable name is empty")
+
+	// ErrLogSamplingEnvironmentVariableNameEmpty is returned when the log sampling
+	// environment variable name is empty.
+	ErrLogSamplingEnvironmentVariableNameEmpty = errors.New("log sampling environment variable name is empty")
+
+	// ErrHelpColorInvalid is returned when the help color is invalid.
+	ErrHelpColorInvalid = errors.New("invalid help color")
+
+	// ErrHelpTemplateInvalid is returned when the help or usage template cannot
+	// be parsed.
+	ErrHelpTemplateInvalid = errors.New("invalid help template")
+
 	// ErrLogOutputEmpty is returned when the log output is empty or nil.
 	ErrLogOutputEmpty = errors.New("log output is empty")
 
+	// ErrNoInputEnvironmentVariableNameEmpty is returned when the no input
+	// environment variable name is empty.
+	ErrNoInputEnvironmentVariableNameEmpty = errors.New("no input environment variable name is empty")
+
+	// ErrOutputEnvironmentVariableNameEmpty is returned when the output
+	// environment variable name is empty.
+	ErrOutputEnvironmentVariableNameEmpty = errors.New("output environment variable name is empty")
+
+	// ErrOutputFormatInvalid is returned when the default output format is
+	// invalid.
+	ErrOutputFormatInvalid = errors.New("invalid output format")
+
+	// ErrShutdownTimeoutInvalid is returned when the shutdown timeout is negative.
+	ErrShutdownTimeoutInvalid = errors.New("shutdown timeout is invalid")
+
 	// ErrFlagEnvVarInvalid is returned when an environment variable value cannot
 	// be parsed into the type required by a flag.
 	ErrFlagEnvVarInvalid = errors.New("environment variable value is invalid for flag type")
+
+	// ErrFlagDuplicate is returned when a flag added by Run is already defined
+	// on the root command.
+	ErrFlagDuplicate = errors.New("flag is already defined")
+
+	// ErrFlagValueInvalid is returned when the default value of a flag is
+	// invalid.
+	ErrFlagValueInvalid = errors.New("flag value is invalid")
+
+	// ErrCommandPanicked is returned by RecoverMiddleware when the command it
+	// wraps panics.
+	ErrCommandPanicked = errors.New("command panicked")
+
+	// ErrConfirmationDeclined is returned when the confirmation of a command
+	// added with WithConfirmation is declined.
+	ErrConfirmationDeclined = errors.New("confirmation was declined")
+
+	// ErrConfirmationEnvironmentVariableNameEmpty is returned when the
+	// confirmation environment variable name is empty.
+	ErrConfirmationEnvironmentVariableNameEmpty = errors.New("confirmation environment variable name is empty")
+
+	// ErrConfirmationRequired is returned when the confirmation of a command
+	// added with WithConfirmation cannot be asked because the input is not
+	// interactive, and it was not skipped with the `--yes` flag.
+	ErrConfirmationRequired = errors.New("confirmation is required")
+
+	// ErrIncompatible is returned when a command tree is not backwards
+	// compatible with a saved description of it.
+	ErrIncompatible = errors.New("command is incompatible")
+
+	// ErrGroupDuplicate is returned when a group with the same ID is added to a
+	// command more than once.
+	ErrGroupDuplicate = errors.New("group is already defined")
+
+	// ErrGroupNotFound is returned when a group ID refers to a group that is not
+	// defined on the parent command.
+	ErrGroupNotFound = errors.New("group is not defined")
+
+	// ErrHandlerOptionsInvalid is returned when the options passed to a handler
+	// cannot be populated.
+	ErrHandlerOptionsInvalid = errors.New("handler options are invalid")
+
+	// ErrProviderCycle is returned when a provider requests the type it
+	// provides while constructing it, directly or through other providers.
+	ErrProviderCycle = errors.New("provider cycle detected for type")
+
+	// ErrProviderDuplicate is returned when more than one provider for the same
+	// type is registered on a command.
+	ErrProviderDuplicate = errors.New("provider is already registered for type")
+
+	// ErrProviderNotFound is returned when no provider is registered for the
+	// requested type.
+	ErrProviderNotFound = errors.New("provider is not registered for type")
+
+	// ErrSpecHandlerNotFound is returned when a command specification references
+	// a handler that is not registered.
+	ErrSpecHandlerNotFound = errors.New("spec handler is not registered")
+
+	// ErrSpecInvalid is returned when a command specification cannot be parsed
+	// or contains invalid values.
+	ErrSpecInvalid = errors.New("spec is invalid")
+
+	// ErrShutdownHookPanicked is returned when a shutdown hook panics.
+	ErrShutdownHookPanicked = errors.New("shutdown hook panicked")
+
+	// ErrShutdownTimeout is returned when the shutdown hooks do not complete
+	// within the configured shutdown timeout.
+	ErrShutdownTimeout = errors.New("shutdown timed out")
 )
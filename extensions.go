@@ -0,0 +1,147 @@
+package snek
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ronelliott/snek/output"
+	"github.com/ronelliott/snek/prompt"
+)
+
+// extensions holds the snek specific values associated with a command that
+// cobra does not provide a field for.
+type extensions struct {
+	// mu guards the values that may be modified while a command is executing.
+	mu sync.Mutex
+
+	// middleware is the middleware registered on the command with
+	// WithMiddleware, in the order it was registered.
+	middleware []Middleware
+
+	// completionCommandGroupID is the group ID of the completion command set on
+	// the command with WithCompletionCommandGroupID.
+	completionCommandGroupID string
+
+	// groups are the groups added to the command with WithGroup, which are
+	// added to the command by NewCommand in the order set with WithGroupOrder.
+	groups []*cobra.Group
+
+	// groupOrder is the order of the group IDs set on the command with
+	// WithGroupOrder.
+	groupOrder []string
+
+	// shutdownHooks is the shutdown hooks registered on a root command with
+	// OnShutdown, in the order they were registered.
+	shutdownHooks []ShutdownHook
+
+	// environmentVariables are the environment variables named by the env
+	// tags of the options of the handler set on the command with WithHandler.
+	environmentVariables []string
+
+	// providers is the providers registered on the command with WithProvider,
+	// keyed by the type of value they provide.
+	providers map[reflect.Type]*provider
+
+	// output is the output options set by the output flags added to a root
+	// command by Run.
+	output *output.Options
+
+	// confirmation is the confirmation set on the command with
+	// WithConfirmation or WithTypedConfirmation.
+	confirmation *confirmation
+
+	// logger is the logger of a root command returned by Logger, created
+	// by Run when the command executes.
+	logger *ScopedLogger
+
+	// prompter is the prompter of a root command returned by Prompter.
+	prompter *prompt.Prompter
+}
+
+// extensionsFlagName is the name of the hidden flag that holds the extensions
+// of a command, so they are kept with the command itself and released along
+// with it. Flag names on the command line end at the first equals sign, so the
+// flag cannot be set from the command line.
+const extensionsFlagName = "snek=extensions"
+
+// extensionsMu guards the creation of the extensions of every command.
+var extensionsMu sync.Mutex
+
+// extensionsValue is the value of the hidden flag holding the extensions of a
+// command.
+type extensionsValue struct {
+	ext *extensions
+}
+
+// String returns an empty string, as the extensions have no textual form.
+func (v *extensionsValue) String() string { return "" }
+
+// Set returns an error, as the extensions cannot be set from the command line.
+func (v *extensionsValue) Set(string) error {
+	return errors.New("snek extensions cannot be set")
+}
+
+// Type returns the type of the flag.
+func (v *extensionsValue) Type() string { return "extensions" }
+
+// extensionsFor returns the extensions associated with the specified command,
+// creating them if they do not exist yet.
+func extensionsFor(cmd *Command) *extensions {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+
+	if ext := extensionsOf(cmd); ext != nil {
+		return ext
+	}
+
+	ext := &extensions{}
+	cmd.Flags().AddFlag(&pflag.Flag{
+		Name:   extensionsFlagName,
+		Value:  &extensionsValue{ext: ext},
+		Hidden: true,
+	})
+	return ext
+}
+
+// lookupExtensions returns the extensions associated with the specified
+// command, or nil if no extensions were ever associated with it.
+func lookupExtensions(cmd *Command) *extensions {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	return extensionsOf(cmd)
+}
+
+// extensionsOf returns the extensions held by the hidden flag of the command,
+// or nil if it has none. The caller must hold extensionsMu.
+func extensionsOf(cmd *Command) *extensions {
+	flag := cmd.Flags().Lookup(extensionsFlagName)
+	if flag == nil {
+		return nil
+	}
+
+	value, ok := flag.Value.(*extensionsValue)
+	if !ok {
+		return nil
+	}
+	return value.ext
+}
+
+// isExtensionsFlag returns true if the flag is the hidden flag holding the
+// extensions of a command.
+func isExtensionsFlag(flag *pflag.Flag) bool {
+	_, ok := flag.Value.(*extensionsValue)
+	return ok
+}
+
+// walkCommands calls fn with the specified command and every one of its
+// descendants, parents before children.
+func walkCommands(cmd *Command, fn func(*Command)) {
+	fn(cmd)
+	for _, child := range cmd.Commands() {
+		walkCommands(child, fn)
+	}
+}
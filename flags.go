@@ -6,6 +6,20 @@ import (
 	"github.com/spf13/pflag"
 )
 
+const (
+	// FlagAnnotationEnum is the flag annotation holding the choices accepted by
+	// an enum flag.
+	FlagAnnotationEnum = "snek_enum"
+
+	// FlagAnnotationEnvironmentVariable is the flag annotation holding the name
+	// of the environment variable that overrides the default value of a flag.
+	FlagAnnotationEnvironmentVariable = "snek_env"
+
+	// FlagAnnotationSecret is the flag annotation marking a flag whose value is
+	// a secret, such as a password, that is prompted for without echoing it.
+	FlagAnnotationSecret = "snek_secret"
+)
+
 // FlagInitializer is a function that initializes a flag on a command.
 type FlagInitializer func(*pflag.FlagSet) error
 
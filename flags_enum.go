@@ -0,0 +1,126 @@
+package snek
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// enumValue is a string flag value that only accepts one of a fixed set of
+// choices.
+type enumValue struct {
+	value   *string
+	choices []string
+}
+
+// newEnumValue returns an enum value storing its value in the specified
+// variable, set to the specified value. If the value is not empty and not one
+// of the choices, then an error is returned.
+func newEnumValue(variable *string, value string, choices []string) (*enumValue, error) {
+	enum := &enumValue{value: variable, choices: choices}
+	if value != "" {
+		if err := enum.Set(value); err != nil {
+			return nil, err
+		}
+	} else {
+		*variable = value
+	}
+	return enum, nil
+}
+
+// Set sets the value of the flag if it is one of the choices.
+func (e *enumValue) Set(value string) error {
+	if !slices.Contains(e.choices, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(e.choices, ", "))
+	}
+	*e.value = value
+	return nil
+}
+
+// String returns the value of the flag.
+func (e *enumValue) String() string {
+	return *e.value
+}
+
+// Type returns the type of the flag displayed in the help output.
+func (e *enumValue) Type() string {
+	return "string"
+}
+
+// addEnumFlag adds an enum flag to the flag set and annotates it with its
+// choices so they can be offered as shell completions.
+func addEnumFlag(flags *pflag.FlagSet, variable *string, name, shorthand, value string, choices []string, usage string) error {
+	enum, err := newEnumValue(variable, value, choices)
+	if err != nil {
+		return fmt.Errorf("%w: %s=%q: %v", ErrFlagValueInvalid, name, value, err)
+	}
+
+	flags.VarP(enum, name, shorthand, usage)
+	return flags.SetAnnotation(name, FlagAnnotationEnum, choices)
+}
+
+// WithEnumVar adds a string flag to the command with the specified name,
+// value, and usage that only accepts one of the specified choices and uses the
+// specified variable to store the value of the flag. An empty value is
+// accepted as the default even when it is not one of the choices.
+//
+// If the value is not one of the choices, then an error wrapping
+// ErrFlagValueInvalid is returned.
+func WithEnumVar(variable *string, name, value string, choices []string, usage string) FlagInitializer {
+	return func(flags *pflag.FlagSet) error {
+		return addEnumFlag(flags, variable, name, "", value, choices, usage)
+	}
+}
+
+// WithEnumVarP adds a string flag to the command with the specified name,
+// shorthand, value, and usage that only accepts one of the specified choices
+// and uses the specified variable to store the value of the flag. An empty
+// value is accepted as the default even when it is not one of the choices.
+//
+// If the value is not one of the choices, then an error wrapping
+// ErrFlagValueInvalid is returned.
+func WithEnumVarP(variable *string, name, shorthand, value string, choices []string, usage string) FlagInitializer {
+	return func(flags *pflag.FlagSet) error {
+		return addEnumFlag(flags, variable, name, shorthand, value, choices, usage)
+	}
+}
+
+// WithEnumVarE adds a string flag to the command with the specified name,
+// value, and usage that only accepts one of the specified choices. If the
+// environment variable envVar is set, its value is used as the default instead
+// of value. The variable stores the final flag value.
+//
+// If the environment variable is not one of the choices, then an error
+// wrapping ErrFlagEnvVarInvalid is returned.
+func WithEnumVarE(variable *string, name, envVar, value string, choices []string, usage string) FlagInitializer {
+	return WithEnumVarPE(variable, name, "", envVar, value, choices, usage)
+}
+
+// WithEnumVarPE adds a string flag to the command with the specified name,
+// shorthand, value, and usage that only accepts one of the specified choices.
+// If the environment variable envVar is set, its value is used as the default
+// instead of value. The variable stores the final flag value.
+//
+// If the environment variable is not one of the choices, then an error
+// wrapping ErrFlagEnvVarInvalid is returned.
+func WithEnumVarPE(variable *string, name, shorthand, envVar, value string, choices []string, usage string) FlagInitializer {
+	if v, ok := os.LookupEnv(envVar); ok {
+		if !slices.Contains(choices, v) {
+			return func(*pflag.FlagSet) error {
+				return fmt.Errorf("%w: %s=%q: must be one of %s",
+					ErrFlagEnvVarInvalid, envVar, v, strings.Join(choices, ", "))
+			}
+		}
+		value = v
+	}
+	return func(flags *pflag.FlagSet) error {
+		if err := addEnumFlag(flags, variable, name, shorthand, value, choices, usage); err != nil {
+			return err
+		}
+
+		return annotateEnvironmentVariable(flags, name, envVar)
+	}
+}
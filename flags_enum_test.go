@@ -0,0 +1,122 @@
+package snek_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+var testEnumChoices = []string{"json", "table", "yaml"}
+
+func TestWithEnumVar(t *testing.T) {
+	runFlagTest(t,
+		func(variable *string, value string) snek.FlagInitializer {
+			return snek.WithEnumVar(variable, "test", value, testEnumChoices, "test enum")
+		},
+		map[string]flagTest[string]{
+			"long flag": {
+				args:     []string{"--test", "yaml"},
+				expected: "yaml",
+			},
+		})
+}
+
+func TestWithEnumVarP(t *testing.T) {
+	runFlagTest(t,
+		func(variable *string, value string) snek.FlagInitializer {
+			return snek.WithEnumVarP(variable, "test", "t", value, testEnumChoices, "test enum")
+		},
+		map[string]flagTest[string]{
+			"long flag": {
+				args:     []string{"--test", "yaml"},
+				expected: "yaml",
+			},
+			"short flag": {
+				args:     []string{"-t", "table"},
+				expected: "table",
+			},
+		})
+}
+
+func TestWithEnumVar_Default(t *testing.T) {
+	var value string
+	cmd, err := snek.NewCommand(snek.WithFlag(snek.WithEnumVar(&value, "test", "json", testEnumChoices, "test enum")))
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Equal(t, "json", value, "The variable should be set to the default")
+	assert.Equal(t, testEnumChoices, cmd.Flags().Lookup("test").Annotations[snek.FlagAnnotationEnum],
+		"The flag should be annotated with its choices")
+}
+
+func TestWithEnumVar_InvalidDefault(t *testing.T) {
+	var value string
+	cmd, err := snek.NewCommand(snek.WithFlag(snek.WithEnumVar(&value, "test", "xml", testEnumChoices, "test enum")))
+	assert.ErrorIs(t, err, snek.ErrFlagValueInvalid, "NewCommand should return an error for an invalid default")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+func TestWithEnumVar_InvalidValue(t *testing.T) {
+	var value string
+	cmd, err := snek.NewCommand(snek.WithFlag(snek.WithEnumVar(&value, "test", "", testEnumChoices, "test enum")))
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	cmd.SetArgs([]string{"--test", "xml"})
+	assert.ErrorContains(t, cmd.Execute(), "must be one of json, table, yaml",
+		"Execute should return an error for a value that is not a choice")
+}
+
+func TestWithEnumVarE(t *testing.T) {
+	runFlagEnvTest(t,
+		"TEST_ENUM",
+		"json",
+		func(variable *string, envVar string, value string) snek.FlagInitializer {
+			return snek.WithEnumVarE(variable, "test", envVar, value, testEnumChoices, "test enum")
+		},
+		map[string]flagEnvTest[string]{
+			"no env var, no flag": {
+				args:     []string{},
+				expected: "json",
+			},
+			"env var set, no flag": {
+				args:     []string{},
+				envValue: "yaml",
+				setEnv:   true,
+				expected: "yaml",
+			},
+			"cli flag overrides env var": {
+				args:     []string{"--test", "table"},
+				envValue: "yaml",
+				setEnv:   true,
+				expected: "table",
+			},
+			"invalid env var": {
+				envValue:    "xml",
+				setEnv:      true,
+				wantInitErr: true,
+			},
+		})
+}
+
+func TestWithEnumVarPE(t *testing.T) {
+	runFlagEnvTest(t,
+		"TEST_ENUM",
+		"json",
+		func(variable *string, envVar string, value string) snek.FlagInitializer {
+			return snek.WithEnumVarPE(variable, "test", "t", envVar, value, testEnumChoices, "test enum")
+		},
+		map[string]flagEnvTest[string]{
+			"env var set, short flag": {
+				args:     []string{"-t", "table"},
+				envValue: "yaml",
+				setEnv:   true,
+				expected: "table",
+			},
+			"invalid env var": {
+				envValue:    "xml",
+				setEnv:      true,
+				wantInitErr: true,
+			},
+		})
+}
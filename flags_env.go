@@ -9,6 +9,18 @@ import (
 	"github.com/spf13/pflag"
 )
 
+// annotateEnvironmentVariable annotates the flag with the name of the
+// environment variable that overrides its default value, so the variable can
+// be listed in help output and generated documentation. If the environment
+// variable name is empty, then the flag is not annotated.
+func annotateEnvironmentVariable(flags *pflag.FlagSet, name, envVar string) error {
+	if envVar == "" {
+		return nil
+	}
+
+	return flags.SetAnnotation(name, FlagAnnotationEnvironmentVariable, []string{envVar})
+}
+
 // WithBoolVarE adds a bool flag to the command with the specified name, value,
 // and usage. If the environment variable envVar is set, its value is used as
 // the default instead of value. The variable stores the final flag value.
@@ -24,7 +36,7 @@ func WithBoolVarE(variable *bool, name, envVar string, value bool, usage string)
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.BoolVar(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -44,7 +56,7 @@ func WithBoolVarPE(variable *bool, name, shorthand, envVar string, value bool, u
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.BoolVarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -63,7 +75,7 @@ func WithDurationVarE(variable *time.Duration, name, envVar string, value time.D
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.DurationVar(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -83,7 +95,7 @@ func WithDurationVarPE(variable *time.Duration, name, shorthand, envVar string,
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.DurationVarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -102,7 +114,7 @@ func WithFloat32VarE(variable *float32, name, envVar string, value float32, usag
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Float32Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -122,7 +134,7 @@ func WithFloat32VarPE(variable *float32, name, shorthand, envVar string, value f
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Float32VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -141,7 +153,7 @@ func WithFloat64VarE(variable *float64, name, envVar string, value float64, usag
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Float64Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -161,7 +173,7 @@ func WithFloat64VarPE(variable *float64, name, shorthand, envVar string, value f
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Float64VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -180,7 +192,7 @@ func WithIntVarE(variable *int, name, envVar string, value int, usage string) Fl
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.IntVar(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -200,7 +212,7 @@ func WithIntVarPE(variable *int, name, shorthand, envVar string, value int, usag
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.IntVarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -219,7 +231,7 @@ func WithInt8VarE(variable *int8, name, envVar string, value int8, usage string)
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Int8Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -239,7 +251,7 @@ func WithInt8VarPE(variable *int8, name, shorthand, envVar string, value int8, u
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Int8VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -258,7 +270,7 @@ func WithInt16VarE(variable *int16, name, envVar string, value int16, usage stri
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Int16Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -278,7 +290,7 @@ func WithInt16VarPE(variable *int16, name, shorthand, envVar string, value int16
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Int16VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -297,7 +309,7 @@ func WithInt32VarE(variable *int32, name, envVar string, value int32, usage stri
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Int32Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -317,7 +329,7 @@ func WithInt32VarPE(variable *int32, name, shorthand, envVar string, value int32
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Int32VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -336,7 +348,7 @@ func WithInt64VarE(variable *int64, name, envVar string, value int64, usage stri
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Int64Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -356,7 +368,7 @@ func WithInt64VarPE(variable *int64, name, shorthand, envVar string, value int64
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Int64VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -375,7 +387,7 @@ func WithUintVarE(variable *uint, name, envVar string, value uint, usage string)
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.UintVar(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -395,7 +407,7 @@ func WithUintVarPE(variable *uint, name, shorthand, envVar string, value uint, u
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.UintVarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -414,7 +426,7 @@ func WithUint8VarE(variable *uint8, name, envVar string, value uint8, usage stri
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Uint8Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -434,7 +446,7 @@ func WithUint8VarPE(variable *uint8, name, shorthand, envVar string, value uint8
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Uint8VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -453,7 +465,7 @@ func WithUint16VarE(variable *uint16, name, envVar string, value uint16, usage s
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Uint16Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -473,7 +485,7 @@ func WithUint16VarPE(variable *uint16, name, shorthand, envVar string, value uin
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Uint16VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -492,7 +504,7 @@ func WithUint32VarE(variable *uint32, name, envVar string, value uint32, usage s
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Uint32Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -512,7 +524,7 @@ func WithUint32VarPE(variable *uint32, name, shorthand, envVar string, value uin
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Uint32VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -531,7 +543,7 @@ func WithUint64VarE(variable *uint64, name, envVar string, value uint64, usage s
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Uint64Var(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -551,7 +563,7 @@ func WithUint64VarPE(variable *uint64, name, shorthand, envVar string, value uin
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.Uint64VarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -564,7 +576,7 @@ func WithStringVarE(variable *string, name, envVar, value, usage string) FlagIni
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.StringVar(variable, name, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
 
@@ -578,6 +590,6 @@ func WithStringVarPE(variable *string, name, shorthand, envVar, value, usage str
 	}
 	return func(flags *pflag.FlagSet) error {
 		flags.StringVarP(variable, name, shorthand, value, usage)
-		return nil
+		return annotateEnvironmentVariable(flags, name, envVar)
 	}
 }
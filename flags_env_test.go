@@ -1184,3 +1184,22 @@ func TestWithStringVarPE(t *testing.T) {
 			},
 		})
 }
+
+func TestFlagEnvAnnotation(t *testing.T) {
+	var value string
+	var choice string
+	cmd, err := snek.NewCommand(snek.WithFlag(
+		snek.WithStringVarE(&value, "test", "TEST_STRING", "", "test string"),
+		snek.WithEnumVarE(&choice, "choice", "TEST_CHOICE", "", []string{"a", "b"}, "test choice"),
+		snek.WithStringVarE(&value, "no-env", "", "", "test string without env var"),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TEST_STRING"},
+		cmd.Flags().Lookup("test").Annotations[snek.FlagAnnotationEnvironmentVariable],
+		"The flag should be annotated with its environment variable")
+	assert.Equal(t, []string{"TEST_CHOICE"},
+		cmd.Flags().Lookup("choice").Annotations[snek.FlagAnnotationEnvironmentVariable],
+		"The enum flag should be annotated with its environment variable")
+	assert.NotContains(t, cmd.Flags().Lookup("no-env").Annotations, snek.FlagAnnotationEnvironmentVariable,
+		"The flag should not be annotated without an environment variable")
+}
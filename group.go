@@ -0,0 +1,124 @@
+package snek
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+// WithGroup adds a command group with the specified ID and title to the
+// command. Subcommands are added to the group using WithGroupID, and are
+// listed under the title of the group in the help output.
+//
+// The group is added to the command once all initializers passed to
+// NewCommand have been called, so the groups can be added in the order set
+// with WithGroupOrder. Groups are listed in the order they are added, after
+// any groups added to the command directly with AddGroup. If a group with the
+// same ID has already been added to the command, then an error wrapping
+// ErrGroupDuplicate is returned.
+func WithGroup(id, title string) Initializer {
+	return func(cmd *Command) error {
+		ext := extensionsFor(cmd)
+		if cmd.ContainsGroup(id) || slices.ContainsFunc(ext.groups, func(group *cobra.Group) bool {
+			return group.ID == id
+		}) {
+			return fmt.Errorf("%w: %s", ErrGroupDuplicate, id)
+		}
+
+		ext.groups = append(ext.groups, &cobra.Group{ID: id, Title: title})
+		return nil
+	}
+}
+
+// WithGroupID sets the ID of the group the command is listed under in the help
+// output of its parent.
+func WithGroupID(id string) Initializer {
+	return func(cmd *Command) error {
+		cmd.GroupID = id
+		return nil
+	}
+}
+
+// WithGroupOrder sets the order the groups of the command are listed in the
+// help output. Groups are ordered once all initializers passed to NewCommand
+// have been called, so it may be specified before or after the groups are
+// added. Groups that are not specified are listed after the specified groups,
+// in the order they were added.
+//
+// If any of the specified IDs are not a group added to the command with
+// WithGroup, then NewCommand returns an error wrapping ErrGroupNotFound.
+func WithGroupOrder(ids ...string) Initializer {
+	return func(cmd *Command) error {
+		ext := extensionsFor(cmd)
+		ext.groupOrder = ids
+		return nil
+	}
+}
+
+// WithCompletionCommandGroupID sets the ID of the group the default completion
+// command is listed under in the help output.
+func WithCompletionCommandGroupID(id string) Initializer {
+	return func(cmd *Command) error {
+		cmd.SetCompletionCommandGroupID(id)
+
+		// cobra does not expose the completion command group ID, so it is also
+		// kept for the completion command added by Run.
+		extensionsFor(cmd).completionCommandGroupID = id
+		return nil
+	}
+}
+
+// WithHelpCommandGroupID sets the ID of the group the default help command is
+// listed under in the help output.
+func WithHelpCommandGroupID(id string) Initializer {
+	return func(cmd *Command) error {
+		cmd.SetHelpCommandGroupID(id)
+		return nil
+	}
+}
+
+// addGroups adds the groups to the command, with the groups with the specified
+// IDs first, in the order specified.
+func addGroups(cmd *Command, groups []*cobra.Group, ids []string) error {
+	ordered := make([]*cobra.Group, 0, len(groups))
+	seen := map[string]bool{}
+	for _, id := range ids {
+		i := slices.IndexFunc(groups, func(group *cobra.Group) bool { return group.ID == id })
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrGroupNotFound, id)
+		}
+
+		if !seen[id] {
+			ordered = append(ordered, groups[i])
+			seen[id] = true
+		}
+	}
+
+	for _, group := range groups {
+		if !seen[group.ID] {
+			ordered = append(ordered, group)
+		}
+	}
+
+	cmd.AddGroup(ordered...)
+	return nil
+}
+
+// validateGroups checks that every command in the tree that sets a group ID
+// is added to a parent that contains the group, so misconfigured groups are
+// returned as an error wrapping ErrGroupNotFound rather than causing cobra to
+// panic during execution.
+func validateGroups(root *Command) error {
+	var err error
+	walkCommands(root, func(cmd *Command) {
+		if err != nil || !cmd.HasParent() || cmd.GroupID == "" {
+			return
+		}
+
+		if !cmd.Parent().ContainsGroup(cmd.GroupID) {
+			err = fmt.Errorf("%w: %s for command %s", ErrGroupNotFound, cmd.GroupID, cmd.CommandPath())
+		}
+	})
+	return err
+}
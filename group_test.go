@@ -0,0 +1,143 @@
+package snek_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func newGroupTestSubCommand(t *testing.T, name, groupID string) *cobra.Command {
+	t.Helper()
+	cmd, err := snek.NewCommand(
+		snek.WithUse(name),
+		snek.WithShort("The "+name+" command"),
+		snek.WithGroupID(groupID),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	return cmd
+}
+
+func TestWithGroup(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithGroup("core", "Core Commands"))
+	require.NoError(t, err, "NewCommand should not return an error")
+	require.Len(t, cmd.Groups(), 1, "The group should be added")
+	assert.Equal(t, "core", cmd.Groups()[0].ID, "The group ID should be set")
+	assert.Equal(t, "Core Commands", cmd.Groups()[0].Title, "The group title should be set")
+}
+
+func TestWithGroup_Duplicate(t *testing.T) {
+	cmd, err := snek.NewCommand(
+		snek.WithGroup("core", "Core Commands"),
+		snek.WithGroup("core", "Other Commands"),
+	)
+	assert.ErrorIs(t, err, snek.ErrGroupDuplicate, "NewCommand should return an error for duplicate groups")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+func TestWithGroupID(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithGroupID("core"))
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Equal(t, "core", cmd.GroupID, "GroupID should be set")
+}
+
+func TestWithGroupOrder(t *testing.T) {
+	cmd, err := snek.NewCommand(
+		snek.WithGroupOrder("debug", "core"),
+		snek.WithGroup("core", "Core Commands"),
+		snek.WithGroup("management", "Management Commands"),
+		snek.WithGroup("debug", "Debug Commands"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	var ids []string
+	for _, group := range cmd.Groups() {
+		ids = append(ids, group.ID)
+	}
+	assert.Equal(t, []string{"debug", "core", "management"}, ids,
+		"Ordered groups should be listed first, followed by the remaining groups")
+}
+
+func TestWithGroupOrder_AddedGroups(t *testing.T) {
+	addGroup := func(cmd *cobra.Command) error {
+		cmd.AddGroup(&cobra.Group{ID: "added", Title: "Added Commands"})
+		return nil
+	}
+
+	cmd, err := snek.NewCommand(
+		snek.WithGroup("core", "Core Commands"),
+		addGroup,
+		snek.WithGroup("debug", "Debug Commands"),
+		snek.WithGroupOrder("debug"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	var ids []string
+	for _, group := range cmd.Groups() {
+		ids = append(ids, group.ID)
+	}
+	assert.Equal(t, []string{"added", "debug", "core"}, ids,
+		"Groups added directly should be listed before the groups added with WithGroup")
+
+	cmd, err = snek.NewCommand(addGroup, snek.WithGroupOrder("added"))
+	assert.ErrorIs(t, err, snek.ErrGroupNotFound, "Groups added directly should not be ordered")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+func TestWithGroupOrder_NotFound(t *testing.T) {
+	cmd, err := snek.NewCommand(
+		snek.WithGroup("core", "Core Commands"),
+		snek.WithGroupOrder("missing"),
+	)
+	assert.ErrorIs(t, err, snek.ErrGroupNotFound, "NewCommand should return an error for unknown groups")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+func TestRun_Groups_HelpOrder(t *testing.T) {
+	var out bytes.Buffer
+	err := snek.Run([]string{"--help"}, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithUse("root"),
+		snek.WithGroup("debug", "Debug Commands:"),
+		snek.WithGroup("management", "Management Commands:"),
+		snek.WithGroup("core", "Core Commands:"),
+		snek.WithGroupOrder("core", "management", "debug"),
+		snek.WithSubCommand(
+			newGroupTestSubCommand(t, "trace", "debug"),
+			newGroupTestSubCommand(t, "users", "management"),
+			newGroupTestSubCommand(t, "serve", "core"),
+			newGroupTestSubCommand(t, "migrate", "core"),
+		),
+		func(cmd *cobra.Command) error {
+			cmd.SetOut(&out)
+			return nil
+		},
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	help := out.String()
+	core := strings.Index(help, "Core Commands:")
+	management := strings.Index(help, "Management Commands:")
+	debug := strings.Index(help, "Debug Commands:")
+	require.True(t, core >= 0 && management >= 0 && debug >= 0, "The help should list every group")
+	assert.Less(t, core, management, "Core commands should be listed before management commands")
+	assert.Less(t, management, debug, "Management commands should be listed before debug commands")
+	assert.Less(t, strings.Index(help, "migrate"), strings.Index(help, "serve"),
+		"Commands should be sorted within their group")
+	assert.Less(t, core, strings.Index(help, "migrate"), "Commands should be listed under their group")
+}
+
+func TestRun_Groups_NotFound(t *testing.T) {
+	err := snek.Run(nil, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithUse("root"),
+		snek.WithSubCommand(newGroupTestSubCommand(t, "serve", "missing")),
+	)
+	assert.ErrorIs(t, err, snek.ErrGroupNotFound,
+		"Run should return an error if a subcommand refers to an unknown group")
+}
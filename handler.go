@@ -0,0 +1,241 @@
+package snek
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// durationType is the reflected type of time.Duration, which is parsed with
+// time.ParseDuration rather than as a plain int64.
+var durationType = reflect.TypeFor[time.Duration]()
+
+// WithHandler sets the run function on the command to a function that accepts
+// the command context, an options struct of type O and the positional
+// arguments. Before the handler is called, a new O is populated using the
+// following struct tags on its exported fields:
+//
+//	flag:    The name of the flag, local or inherited, to read the value from.
+//	env:     The environment variable to read the value from when the flag was
+//	         not set on the command line. Like the environment variables of
+//	         the flags added with WithStringVarE and the like, the name is
+//	         absolute: the environment variable prefix configured with
+//	         WithEnvironmentVariablePrefix is not prepended to it.
+//	default: The value to use when neither the flag nor the environment
+//	         variable provide one.
+//
+// When a field has a flag tag, the default value of the flag is used when the
+// flag was not set on the command line and the environment variable is not
+// set. Fields without any of these tags are left as their zero value. Slice
+// fields read from slice flags receive the elements of the flag as they are,
+// while slice fields read from an environment variable or default are parsed
+// as comma separated lists. The environment variables named by env tags are
+// known to the environment variable check enabled with
+// WithEnvironmentVariableCheck.
+//
+// If O is not a struct, then an error wrapping ErrHandlerOptionsInvalid is
+// returned. If a flag named by a flag tag does not exist, or a value cannot be
+// parsed into the type of its field, then the handler is not called and an
+// error wrapping ErrHandlerOptionsInvalid is returned from the run function.
+func WithHandler[O any](handler func(ctx context.Context, opts O, args []string) error) Initializer {
+	return func(cmd *Command) error {
+		typ := reflect.TypeFor[O]()
+		if typ.Kind() != reflect.Struct {
+			return fmt.Errorf("%w: %s is not a struct", ErrHandlerOptionsInvalid, typ)
+		}
+
+		var envVars []string
+		for i := range typ.NumField() {
+			if name, ok := typ.Field(i).Tag.Lookup("env"); ok && typ.Field(i).IsExported() {
+				envVars = append(envVars, name)
+			}
+		}
+		ext := extensionsFor(cmd)
+		ext.mu.Lock()
+		ext.environmentVariables = append(ext.environmentVariables, envVars...)
+		ext.mu.Unlock()
+
+		cmd.RunE = func(cmd *Command, args []string) error {
+			var opts O
+			if err := populateOptions(cmd, reflect.ValueOf(&opts).Elem()); err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			return handler(ctx, opts, args)
+		}
+		return nil
+	}
+}
+
+// populateOptions sets the fields of the options struct from the flags of the
+// command, the environment and the default struct tags.
+func populateOptions(cmd *Command, opts reflect.Value) error {
+	typ := opts.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		value, ok, err := lookupOption(cmd, field)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := setOption(opts.Field(i), value); err != nil {
+			return fmt.Errorf("%w: field %s: %v", ErrHandlerOptionsInvalid, field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// optionValue is the value of an option read from a flag, an environment
+// variable or a default. The elements of a slice flag are kept as they are,
+// so elements containing commas are not split when they are set on a slice
+// field.
+type optionValue struct {
+	// text is the value as a string.
+	text string
+
+	// elements are the elements of a slice flag, when isSlice is true.
+	elements []string
+	isSlice  bool
+}
+
+// lookupOption returns the value for the field from its flag, environment
+// variable or default, in that order of priority. If none of them provide a
+// value, then ok is false.
+func lookupOption(cmd *Command, field reflect.StructField) (value optionValue, ok bool, err error) {
+	var flag *pflag.Flag
+	if name, hasFlag := field.Tag.Lookup("flag"); hasFlag {
+		flag = cmd.Flags().Lookup(name)
+		if flag == nil {
+			flag = cmd.InheritedFlags().Lookup(name)
+		}
+
+		if flag == nil {
+			return optionValue{}, false, fmt.Errorf("%w: field %s: flag %q does not exist",
+				ErrHandlerOptionsInvalid, field.Name, name)
+		}
+
+		if flag.Changed {
+			return flagValue(flag), true, nil
+		}
+	}
+
+	if name, hasEnv := field.Tag.Lookup("env"); hasEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			return optionValue{text: value}, true, nil
+		}
+	}
+
+	if flag != nil {
+		return flagValue(flag), true, nil
+	}
+
+	text, ok := field.Tag.Lookup("default")
+	return optionValue{text: text}, ok, nil
+}
+
+// flagValue returns the value of the flag. The elements of slice flags are
+// copied from the flag, and their text is a comma separated list rather than
+// their bracketed form.
+func flagValue(flag *pflag.Flag) optionValue {
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		elements := slice.GetSlice()
+		return optionValue{text: strings.Join(elements, ","), elements: elements, isSlice: true}
+	}
+
+	return optionValue{text: flag.Value.String()}
+}
+
+// setOption parses the value into the type of the field and sets the field.
+// Slices are set from the elements of slice flags, or parsed as comma
+// separated lists of their element type otherwise.
+func setOption(field reflect.Value, value optionValue) error {
+	if field.Kind() != reflect.Slice {
+		return parseOption(field, value.text)
+	}
+
+	elements := value.elements
+	if !value.isSlice {
+		elements = nil
+		if value.text != "" {
+			elements = strings.Split(value.text, ",")
+			for i := range elements {
+				elements[i] = strings.TrimSpace(elements[i])
+			}
+		}
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(elements), len(elements))
+	for i, element := range elements {
+		if err := parseOption(slice.Index(i), element); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// parseOption parses the value into the type of the field, which is not a
+// slice, and sets the field.
+func parseOption(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(parsed))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.String:
+		field.SetString(value)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package snek_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+type handlerTestOptions struct {
+	Name     string        `flag:"name"`
+	Count    int           `flag:"count" env:"TEST_HANDLER_COUNT"`
+	Timeout  time.Duration `flag:"timeout"`
+	Tags     []string      `flag:"tags"`
+	Region   string        `env:"TEST_HANDLER_REGION" default:"us-east-1"`
+	Verbose  bool          `default:"true"`
+	Untagged string
+}
+
+func newHandlerTestCommand(t *testing.T, handler func(context.Context, handlerTestOptions, []string) error) *snek.Command {
+	t.Helper()
+	var (
+		name    string
+		count   int
+		timeout time.Duration
+		tags    []string
+	)
+	cmd, err := snek.NewCommand(
+		snek.WithFlag(
+			snek.WithStringVar(&name, "name", "default-name", "The name"),
+			snek.WithIntVar(&count, "count", 1, "The count"),
+			snek.WithDurationVar(&timeout, "timeout", time.Second, "The timeout"),
+			func(flags *pflag.FlagSet) error {
+				flags.StringSliceVar(&tags, "tags", nil, "The tags")
+				return nil
+			},
+		),
+		snek.WithHandler(handler),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	return cmd
+}
+
+func TestWithHandler_Defaults(t *testing.T) {
+	var got handlerTestOptions
+	cmd := newHandlerTestCommand(t, func(ctx context.Context, opts handlerTestOptions, args []string) error {
+		got = opts
+		assert.NotNil(t, ctx, "The handler should be passed a context")
+		assert.Equal(t, []string{"arg"}, args, "The handler should be passed the positional arguments")
+		return nil
+	})
+
+	cmd.SetArgs([]string{"arg"})
+	require.NoError(t, cmd.Execute(), "Execute should not return an error")
+	assert.Equal(t, handlerTestOptions{
+		Name:    "default-name",
+		Count:   1,
+		Timeout: time.Second,
+		Tags:    []string{},
+		Region:  "us-east-1",
+		Verbose: true,
+	}, got, "The options should be populated from the flag defaults and default tags")
+}
+
+func TestWithHandler_FlagsAndEnvironment(t *testing.T) {
+	t.Setenv("TEST_HANDLER_COUNT", "5")
+	t.Setenv("TEST_HANDLER_REGION", "eu-west-1")
+
+	var got handlerTestOptions
+	cmd := newHandlerTestCommand(t, func(ctx context.Context, opts handlerTestOptions, args []string) error {
+		got = opts
+		return nil
+	})
+
+	cmd.SetArgs([]string{"--name", "foo", "--timeout", "1m", "--tags", "a,b"})
+	require.NoError(t, cmd.Execute(), "Execute should not return an error")
+	assert.Equal(t, handlerTestOptions{
+		Name:    "foo",
+		Count:   5,
+		Timeout: time.Minute,
+		Tags:    []string{"a", "b"},
+		Region:  "eu-west-1",
+		Verbose: true,
+	}, got, "The options should be populated from the flags and environment")
+}
+
+func TestWithHandler_FlagOverridesEnvironment(t *testing.T) {
+	t.Setenv("TEST_HANDLER_COUNT", "5")
+
+	var got handlerTestOptions
+	cmd := newHandlerTestCommand(t, func(ctx context.Context, opts handlerTestOptions, args []string) error {
+		got = opts
+		return nil
+	})
+
+	cmd.SetArgs([]string{"--count", "7"})
+	require.NoError(t, cmd.Execute(), "Execute should not return an error")
+	assert.Equal(t, 7, got.Count, "The flag should take priority over the environment variable")
+}
+
+func TestWithHandler_InvalidValue(t *testing.T) {
+	t.Setenv("TEST_HANDLER_COUNT", "invalid")
+
+	called := false
+	cmd := newHandlerTestCommand(t, func(ctx context.Context, opts handlerTestOptions, args []string) error {
+		called = true
+		return nil
+	})
+
+	cmd.SetArgs([]string{})
+	assert.ErrorIs(t, cmd.Execute(), snek.ErrHandlerOptionsInvalid,
+		"Execute should return an error if a value cannot be parsed")
+	assert.False(t, called, "The handler should not be called")
+}
+
+func TestWithHandler_MissingFlag(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithHandler(
+		func(ctx context.Context, opts struct {
+			Missing string `flag:"missing"`
+		}, args []string) error {
+			return nil
+		}))
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	cmd.SetArgs([]string{})
+	assert.ErrorIs(t, cmd.Execute(), snek.ErrHandlerOptionsInvalid,
+		"Execute should return an error if a flag does not exist")
+}
+
+func TestWithHandler_NotStruct(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithHandler(func(context.Context, string, []string) error {
+		return nil
+	}))
+	assert.ErrorIs(t, err, snek.ErrHandlerOptionsInvalid,
+		"NewCommand should return an error if the options are not a struct")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+func TestWithHandler_Error(t *testing.T) {
+	cmd := newHandlerTestCommand(t, func(context.Context, handlerTestOptions, []string) error {
+		return assert.AnError
+	})
+
+	cmd.SetArgs([]string{})
+	assert.ErrorIs(t, cmd.Execute(), assert.AnError, "Execute should return the error returned by the handler")
+}
+
+func TestWithHandler_SliceElementsWithCommas(t *testing.T) {
+	type options struct {
+		Filters []string `flag:"filter"`
+	}
+
+	var filters []string
+	var got options
+	cmd, err := snek.NewCommand(
+		snek.WithFlag(func(flags *pflag.FlagSet) error {
+			flags.StringArrayVar(&filters, "filter", nil, "The filters")
+			return nil
+		}),
+		snek.WithHandler(func(_ context.Context, opts options, _ []string) error {
+			got = opts
+			return nil
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	cmd.SetArgs([]string{"--filter", "name in (a,b)", "--filter", "size > 1"})
+	require.NoError(t, cmd.Execute(), "Execute should not return an error")
+	assert.Equal(t, []string{"name in (a,b)", "size > 1"}, got.Filters,
+		"The elements of the slice flag should not be split on commas")
+}
+
+func TestRun_Handler_KnownEnvironmentVariables(t *testing.T) {
+	t.Setenv("APP_TOKEN", "secret")
+
+	var warnings bytes.Buffer
+	var token string
+	err := snek.Run(nil, snek.NewConfig(
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithEnvironmentVariableCheck(true),
+		snek.WithLogOutput(&warnings),
+		snek.WithDefaultLogLevel("warn"),
+	),
+		snek.WithUse("app"),
+		snek.WithHandler(func(_ context.Context, opts struct {
+			Token string `env:"APP_TOKEN"`
+		}, _ []string) error {
+			token = opts.Token
+			return nil
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, "secret", token, "The env tag should name the environment variable without the prefix")
+	assert.Empty(t, warnings.String(), "The environment variables of env tags should be known")
+}
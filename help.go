@@ -0,0 +1,351 @@
+package snek
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+const (
+	// HelpColorAuto colors the help output when it is written to a terminal
+	// and the `NO_COLOR` environment variable is not set.
+	HelpColorAuto = "auto"
+
+	// HelpColorAlways always colors the help output.
+	HelpColorAlways = "always"
+
+	// HelpColorNever never colors the help output.
+	HelpColorNever = "never"
+
+	// defaultHelpWidth is the width the help output is wrapped to when it is not
+	// written to a terminal and no width is configured.
+	defaultHelpWidth = 80
+
+	// minHelpUsageWidth is the minimum width of the usage column of the flags.
+	// Narrower columns are not wrapped, as wrapping them would be unreadable.
+	minHelpUsageWidth = 24
+)
+
+// DefaultHelpTemplate is the template used by the snek help renderer to render
+// the help of a command. The usage of the command is rendered with the `usage`
+// function.
+const DefaultHelpTemplate = `{{with (or .Long .Short)}}{{wrap . | trimTrailingWhitespaces}}
+
+{{end}}{{if or .Runnable .HasSubCommands}}{{usage .}}{{end}}`
+
+// DefaultUsageTemplate is the template used by the snek help renderer to render
+// the usage of a command. In addition to the functions provided by cobra, the
+// template can use the following functions:
+//
+//	command: Colors the name of a command.
+//	flagUsages: Renders the usage of a flag set, including the default value and environment variable of each flag.
+//	heading: Colors a heading.
+//	usage: Renders the usage of a command.
+//	wrap: Wraps text to the width of the help output.
+const DefaultUsageTemplate = `{{heading "Usage:"}}{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+{{heading "Aliases:"}}
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+{{heading "Examples:"}}
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}{{$cmds := .Commands}}{{if eq (len .Groups) 0}}
+
+{{heading "Available Commands:"}}{{range $cmds}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{command (rpad .Name .NamePadding)}} {{.Short}}{{end}}{{end}}{{else}}{{range $group := .Groups}}
+
+{{heading .Title}}{{range $cmds}}{{if (and (eq .GroupID $group.ID) (or .IsAvailableCommand (eq .Name "help")))}}
+  {{command (rpad .Name .NamePadding)}} {{.Short}}{{end}}{{end}}{{end}}{{if not .AllChildCommandsHaveGroup}}
+
+{{heading "Additional Commands:"}}{{range $cmds}}{{if (and (eq .GroupID "") (or .IsAvailableCommand (eq .Name "help")))}}
+  {{command (rpad .Name .NamePadding)}} {{.Short}}{{end}}{{end}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+{{heading "Flags:"}}
+{{flagUsages .LocalFlags | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+{{heading "Global Flags:"}}
+{{flagUsages .InheritedFlags | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
+
+{{heading "Additional help topics:"}}{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
+  {{command (rpad .CommandPath .CommandPathPadding)}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`
+
+// HelpTheme is the colors used by the snek help renderer. Each color is an
+// ANSI SGR parameter string, such as "1" for bold or "1;36" for bold cyan. An
+// empty color leaves the text uncolored.
+type HelpTheme struct {
+	// Command is the color of the names of subcommands.
+	Command string
+
+	// Flag is the color of the names of flags.
+	Flag string
+
+	// Heading is the color of section headings, such as "Usage:" and "Flags:".
+	Heading string
+}
+
+// DefaultHelpTheme returns the default theme of the snek help renderer.
+func DefaultHelpTheme() HelpTheme {
+	return HelpTheme{
+		Command: "36",
+		Flag:    "36",
+		Heading: "1",
+	}
+}
+
+// helpStyle is the style the help of a command is rendered with, determined
+// by the configuration and the writer the help is written to.
+type helpStyle struct {
+	color bool
+	theme HelpTheme
+	width int
+}
+
+// newHelpStyle returns the style of the help written to the specified writer.
+func newHelpStyle(cfg *Config, w io.Writer) *helpStyle {
+	fd, terminal := terminalFd(w)
+
+	style := &helpStyle{theme: cfg.HelpTheme, width: cfg.HelpWidth}
+	switch cfg.HelpColor {
+	case HelpColorAlways:
+		style.color = true
+	case HelpColorAuto, "":
+		style.color = terminal && os.Getenv("NO_COLOR") == ""
+	}
+
+	if style.width <= 0 {
+		style.width = defaultHelpWidth
+		if terminal {
+			if width, _, err := term.GetSize(fd); err == nil && width > 0 {
+				style.width = width
+			}
+		}
+	}
+
+	return style
+}
+
+// terminalFd returns the file descriptor of the writer and whether it is a
+// terminal.
+func terminalFd(w io.Writer) (int, bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0, false
+	}
+
+	fd := int(f.Fd())
+	return fd, term.IsTerminal(fd)
+}
+
+// colorize wraps the text in the specified ANSI color if coloring is enabled.
+func (s *helpStyle) colorize(color, text string) string {
+	if !s.color || color == "" {
+		return text
+	}
+	return "\x1b[" + color + "m" + text + "\x1b[0m"
+}
+
+// funcs returns the functions available to the help and usage templates.
+func (s *helpStyle) funcs(cfg *Config) template.FuncMap {
+	return template.FuncMap{
+		"trim":                    strings.TrimSpace,
+		"trimRightSpace":          trimRightSpace,
+		"trimTrailingWhitespaces": trimRightSpace,
+		"rpad":                    rpad,
+		"gt":                      cobra.Gt,
+		"eq":                      cobra.Eq,
+		"command":                 func(text string) string { return s.colorize(s.theme.Command, text) },
+		"heading":                 func(text string) string { return s.colorize(s.theme.Heading, text) },
+		"flagUsages":              s.flagUsages,
+		"wrap":                    func(text string) string { return wrapText(text, s.width, 0) },
+		"usage": func(cmd *Command) (string, error) {
+			var buf bytes.Buffer
+			err := s.render(&buf, cfg, helpTemplate(cfg.UsageTemplate, DefaultUsageTemplate), cmd)
+			return buf.String(), err
+		},
+	}
+}
+
+// render renders the template text with the command to the writer.
+func (s *helpStyle) render(w io.Writer, cfg *Config, text string, cmd *Command) error {
+	tmpl, err := template.New("help").Funcs(s.funcs(cfg)).Parse(text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, cmd)
+}
+
+// flagUsages renders the usage of every flag in the flag set that is not
+// hidden, including the default value and environment variable of the flag.
+// The usage is wrapped to the width of the help output.
+func (s *helpStyle) flagUsages(flags *pflag.FlagSet) string {
+	type flagUsage struct {
+		names string
+		usage string
+	}
+
+	var usages []flagUsage
+	namesWidth := 0
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+
+		names := "      --" + flag.Name
+		if flag.Shorthand != "" && flag.ShorthandDeprecated == "" {
+			names = fmt.Sprintf("  -%s, --%s", flag.Shorthand, flag.Name)
+		}
+
+		varname, usage := pflag.UnquoteUsage(flag)
+		if varname != "" {
+			names += " " + varname
+		}
+
+		var details []string
+		if !isZeroDefault(flag) {
+			if flag.Value.Type() == "string" {
+				details = append(details, fmt.Sprintf("default %q", flag.DefValue))
+			} else {
+				details = append(details, "default "+flag.DefValue)
+			}
+		}
+
+		if envVars := flag.Annotations[FlagAnnotationEnvironmentVariable]; len(envVars) > 0 {
+			details = append(details, "env: "+envVars[0])
+		}
+
+		if len(details) > 0 {
+			usage += " (" + strings.Join(details, ", ") + ")"
+		}
+
+		if flag.Deprecated != "" {
+			usage += fmt.Sprintf(" (DEPRECATED: %s)", flag.Deprecated)
+		}
+
+		namesWidth = max(namesWidth, len(names))
+		usages = append(usages, flagUsage{names: names, usage: usage})
+	})
+
+	var buf strings.Builder
+	indent := namesWidth + 3
+	for _, usage := range usages {
+		buf.WriteString(s.colorize(s.theme.Flag, usage.names))
+		buf.WriteString(strings.Repeat(" ", indent-len(usage.names)))
+		if s.width-indent >= minHelpUsageWidth {
+			buf.WriteString(wrapText(usage.usage, s.width, indent))
+		} else {
+			buf.WriteString(usage.usage)
+		}
+		buf.WriteString("\n")
+	}
+
+	return buf.String()
+}
+
+// isZeroDefault returns true if the default value of the flag is the zero
+// value of its type, in which case it is not displayed.
+func isZeroDefault(flag *pflag.Flag) bool {
+	switch flag.DefValue {
+	case "", "false", "0", "0s", "[]", "<nil>":
+		return true
+	}
+	return false
+}
+
+// wrapText wraps each line of the text that is longer than the width at word
+// boundaries. Every line after the first is indented by the specified number of
+// spaces, and lines starting with whitespace, such as code examples, are not
+// wrapped.
+func wrapText(text string, width, indent int) string {
+	padding := strings.Repeat(" ", indent)
+	lines := strings.Split(text, "\n")
+	var wrapped []string
+	for _, line := range lines {
+		if indent+len(line) <= width || strings.TrimLeft(line, " \t") != line {
+			wrapped = append(wrapped, line)
+			continue
+		}
+
+		var current strings.Builder
+		for _, word := range strings.Fields(line) {
+			if current.Len() > 0 && indent+current.Len()+1+len(word) > width {
+				wrapped = append(wrapped, current.String())
+				current.Reset()
+			}
+
+			if current.Len() > 0 {
+				current.WriteString(" ")
+			}
+			current.WriteString(word)
+		}
+		wrapped = append(wrapped, current.String())
+	}
+
+	return strings.Join(wrapped, "\n"+padding)
+}
+
+// helpTemplate returns the custom template if it is not empty, otherwise the
+// default template.
+func helpTemplate(custom, fallback string) string {
+	if custom != "" {
+		return custom
+	}
+	return fallback
+}
+
+// installHelpRenderer sets the help and usage functions of the root command,
+// and therefore of every command in the tree that does not set its own, to
+// render the configured templates.
+func installHelpRenderer(root *Command, cfg *Config) {
+	root.SetHelpFunc(func(cmd *Command, args []string) {
+		w := cmd.OutOrStdout()
+		style := newHelpStyle(cfg, w)
+		if err := style.render(w, cfg, helpTemplate(cfg.HelpTemplate, DefaultHelpTemplate), cmd); err != nil {
+			cmd.PrintErrln(err)
+		}
+	})
+
+	root.SetUsageFunc(func(cmd *Command) error {
+		w := cmd.OutOrStderr()
+		return newHelpStyle(cfg, w).render(w, cfg, helpTemplate(cfg.UsageTemplate, DefaultUsageTemplate), cmd)
+	})
+}
+
+// validateHelpTemplates returns an error if either of the help or usage
+// templates of the configuration cannot be parsed.
+func validateHelpTemplates(cfg *Config) error {
+	funcs := (&helpStyle{}).funcs(cfg)
+	for _, text := range []string{cfg.HelpTemplate, cfg.UsageTemplate} {
+		if text == "" {
+			continue
+		}
+
+		if _, err := template.New("help").Funcs(funcs).Parse(text); err != nil {
+			return fmt.Errorf("%w: %v", ErrHelpTemplateInvalid, err)
+		}
+	}
+
+	return nil
+}
+
+// trimRightSpace returns the text with trailing whitespace removed.
+func trimRightSpace(text string) string {
+	return strings.TrimRightFunc(text, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+}
+
+// rpad pads the text with spaces on the right to the specified width.
+func rpad(text string, width int) string {
+	return fmt.Sprintf("%-*s", width, text)
+}
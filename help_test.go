@@ -0,0 +1,175 @@
+package snek_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func runHelpTest(t *testing.T, cfg *snek.Config, args []string, initializers ...snek.Initializer) string {
+	t.Helper()
+	var out bytes.Buffer
+	initializers = append([]snek.Initializer{
+		snek.WithUse("app"),
+		snek.WithShort("The app"),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+		func(cmd *cobra.Command) error {
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+			return nil
+		},
+	}, initializers...)
+
+	snek.WithLogOutput(io.Discard)(cfg)
+	err := snek.Run(args, cfg, initializers...)
+	require.NoError(t, err, "Run should not return an error")
+	return out.String()
+}
+
+func TestWithHelpColor(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, snek.HelpColorAuto, cfg.HelpColor, "The default help color should be auto")
+	cfg = snek.NewConfig(snek.WithHelpColor(snek.HelpColorNever))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, snek.HelpColorNever, cfg.HelpColor, "HelpColor should be never")
+}
+
+func TestWithHelpRenderer(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.HelpRenderer, "HelpRenderer should be false")
+	cfg = snek.NewConfig(snek.WithHelpRenderer(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.HelpRenderer, "HelpRenderer should be true")
+}
+
+func TestWithHelpTemplate(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Empty(t, cfg.HelpTemplate, "The default help template should be empty")
+	cfg = snek.NewConfig(snek.WithHelpTemplate("{{.Name}}"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "{{.Name}}", cfg.HelpTemplate, "HelpTemplate should be set")
+}
+
+func TestWithHelpTheme(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, snek.DefaultHelpTheme(), cfg.HelpTheme, "The default help theme should be DefaultHelpTheme")
+	theme := snek.HelpTheme{Heading: "1;35"}
+	cfg = snek.NewConfig(snek.WithHelpTheme(theme))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, theme, cfg.HelpTheme, "HelpTheme should be set")
+}
+
+func TestWithHelpWidth(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Zero(t, cfg.HelpWidth, "The default help width should be zero")
+	cfg = snek.NewConfig(snek.WithHelpWidth(100))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, 100, cfg.HelpWidth, "HelpWidth should be 100")
+}
+
+func TestWithUsageTemplate(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Empty(t, cfg.UsageTemplate, "The default usage template should be empty")
+	cfg = snek.NewConfig(snek.WithUsageTemplate("{{.UseLine}}"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "{{.UseLine}}", cfg.UsageTemplate, "UsageTemplate should be set")
+}
+
+func TestRun_HelpRenderer_Invalid(t *testing.T) {
+	err := snek.Run([]string{}, snek.NewConfig(snek.WithHelpColor("sometimes")))
+	assert.ErrorIs(t, err, snek.ErrHelpColorInvalid, "Run should return ErrHelpColorInvalid")
+
+	err = snek.Run([]string{}, snek.NewConfig(snek.WithHelpTemplate("{{.Name")))
+	assert.ErrorIs(t, err, snek.ErrHelpTemplateInvalid, "Run should return ErrHelpTemplateInvalid")
+
+	err = snek.Run([]string{}, snek.NewConfig(snek.WithUsageTemplate("{{unknown .}}")))
+	assert.ErrorIs(t, err, snek.ErrHelpTemplateInvalid, "Run should return ErrHelpTemplateInvalid")
+}
+
+func TestRun_HelpRenderer(t *testing.T) {
+	var name string
+	var port int
+	out := runHelpTest(t,
+		snek.NewConfig(snek.WithHelpRenderer(true), snek.WithHelpWidth(200),
+			snek.WithEnvironmentVariablePrefix("APP_")),
+		[]string{"--help"},
+		snek.WithFlag(
+			snek.WithStringVarE(&name, "name", "APP_NAME", "world", "The name to greet"),
+			snek.WithIntVarP(&port, "port", "p", 0, "The port to bind to"),
+		),
+	)
+
+	assert.True(t, strings.HasPrefix(out, "The app\n\nUsage:\n  app [flags]"), "The help should start with the description and usage")
+	assert.Regexp(t, `--name string +The name to greet \(default "world", env: APP_NAME\)\n`, out,
+		"The help should show the default value and environment variable of the flag")
+	assert.Regexp(t, `-p, --port int +The port to bind to\n`, out,
+		"The help should not show zero default values")
+	assert.Contains(t, out, "(default \"info\", env: APP_LOG_LEVEL)",
+		"The help should show the prefixed environment variables of the log flags")
+	assert.NotContains(t, out, "\x1b[", "The help should not be colored when not written to a terminal")
+}
+
+func TestRun_HelpRenderer_Wrap(t *testing.T) {
+	var name string
+	out := runHelpTest(t,
+		snek.NewConfig(snek.WithHelpRenderer(true), snek.WithHelpWidth(60)),
+		[]string{"--help"},
+		snek.WithLong("This is a long description of the app that should be wrapped to the configured width."),
+		snek.WithFlag(snek.WithStringVar(&name, "name", "", "The name to greet, which is used in a very long sentence")),
+	)
+
+	for _, line := range strings.Split(out, "\n") {
+		assert.LessOrEqual(t, len(line), 60, "Each line should be wrapped to the configured width: %q", line)
+	}
+	assert.Contains(t, out, "This is a long description of the app that should be wrapped\nto the",
+		"The description should be wrapped")
+	assert.Regexp(t, `--name string +The name to greet, which is\n {31}used in a very long sentence\n`, out,
+		"The flag usage should be wrapped and indented")
+}
+
+func TestRun_HelpRenderer_Color(t *testing.T) {
+	out := runHelpTest(t,
+		snek.NewConfig(snek.WithHelpRenderer(true), snek.WithHelpColor(snek.HelpColorAlways),
+			snek.WithHelpTheme(snek.HelpTheme{Heading: "1;35"})),
+		[]string{"--help"},
+	)
+
+	assert.Contains(t, out, "\x1b[1;35mUsage:\x1b[0m", "The headings should be colored")
+	assert.Contains(t, out, "\x1b[1;35mFlags:\x1b[0m", "The headings should be colored")
+	assert.NotContains(t, out, "--help\x1b[0m", "Flags should not be colored without a flag color")
+}
+
+func TestRun_HelpRenderer_Templates(t *testing.T) {
+	sub, err := snek.NewCommand(snek.WithUse("sub"), snek.WithShort("A subcommand"),
+		snek.WithRun(func(*cobra.Command, []string) {}))
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	cfg := snek.NewConfig(
+		snek.WithHelpRenderer(true),
+		snek.WithHelpTemplate("HELP {{.Name}}\n{{usage .}}"),
+		snek.WithUsageTemplate("USAGE {{.CommandPath}}\n"),
+	)
+	out := runHelpTest(t, cfg, []string{"sub", "--help"}, snek.WithSubCommand(sub))
+	assert.Equal(t, "HELP sub\nUSAGE app sub\n", out, "The custom templates should be used for subcommands")
+}
+
+func TestRun_HelpRenderer_Disabled(t *testing.T) {
+	var name string
+	out := runHelpTest(t, snek.NewConfig(), []string{"--help"},
+		snek.WithFlag(snek.WithStringVarE(&name, "name", "APP_NAME", "world", "The name to greet")),
+	)
+	assert.NotContains(t, out, "env: APP_NAME", "The default help should not show environment variables")
+}
@@ -0,0 +1,97 @@
+package snek
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// deduplicatingWriter is a log writer that suppresses logs repeating the
+// previous log within a window. Logs repeat the previous log when they have the
+// same level and fields, other than the time and caller. The number of suppressed
+// logs is written as a summary before the next different log, before the next
+// repeated log once the window has passed, and when the writer is flushed.
+type deduplicatingWriter struct {
+	next   zerolog.LevelWriter
+	window time.Duration
+	fields map[string]any
+
+	mu         sync.Mutex
+	level      zerolog.Level
+	line       string
+	message    string
+	written    time.Time
+	suppressed int
+}
+
+// newDeduplicatingWriter returns a writer that deduplicates the logs written to
+// next within the window. The fields are added to the summaries.
+func newDeduplicatingWriter(next zerolog.LevelWriter, window time.Duration, fields map[string]any) *deduplicatingWriter {
+	return &deduplicatingWriter{next: next, window: window, fields: fields}
+}
+
+// Write writes the log without a level.
+func (w *deduplicatingWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel writes the log, unless it repeats the previous log within the
+// window.
+func (w *deduplicatingWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return w.next.WriteLevel(level, p)
+	}
+
+	var message string
+	_ = json.Unmarshal(fields[zerolog.MessageFieldName], &message)
+	delete(fields, zerolog.TimestampFieldName)
+	delete(fields, zerolog.CallerFieldName)
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return w.next.WriteLevel(level, p)
+	}
+	line := string(encoded)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if level == w.level && line == w.line && !w.written.IsZero() {
+		if now.Sub(w.written) < w.window {
+			w.suppressed++
+			return len(p), nil
+		}
+	}
+
+	w.writeSummary()
+	w.level, w.line, w.message, w.written = level, line, message, now
+	return w.next.WriteLevel(level, p)
+}
+
+// flush writes the summary of the suppressed logs, if any.
+func (w *deduplicatingWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writeSummary()
+	w.written = time.Time{}
+}
+
+// writeSummary writes the number of logs suppressed since the previous log was
+// written, at its level. The caller must hold the lock.
+func (w *deduplicatingWriter) writeSummary() {
+	if w.suppressed == 0 {
+		return
+	}
+
+	logger := zerolog.New(w.next)
+	logger.WithLevel(w.level).
+		Timestamp().
+		Fields(w.fields).
+		Int("suppressed", w.suppressed).
+		Str("similar", w.message).
+		Msgf("Suppressed %d similar messages.", w.suppressed)
+	w.suppressed = 0
+}
@@ -0,0 +1,147 @@
+package snek_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+// runLogDeduplicationTest runs a command that calls run with its logger, and
+// returns the log lines written other than those written when logging is set
+// up.
+func runLogDeduplicationTest(t *testing.T, cfg *snek.Config, args []string, run func(*snek.ScopedLogger)) ([]map[string]any, error) {
+	t.Helper()
+	var logs bytes.Buffer
+	snek.WithLogOutput(&logs)(cfg)
+	snek.WithDefaultLogFormat("json")(cfg)
+	err := snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) {
+			run(snek.Logger(cmd))
+		}),
+	)
+
+	var lines []map[string]any
+	for _, line := range decodeLogLines(t, &logs) {
+		if line["message"] != "Logging initialized." {
+			delete(line, "time")
+			lines = append(lines, line)
+		}
+	}
+	return lines, err
+}
+
+func TestWithLogDeduplication(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Zero(t, cfg.LogDeduplication, "The default log deduplication should be zero")
+	cfg = snek.NewConfig(snek.WithLogDeduplication(time.Minute))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, time.Minute, cfg.LogDeduplication, "WithLogDeduplication should set the window")
+}
+
+func TestRun_LogDeduplication(t *testing.T) {
+	allowAllLogLevels(t)
+
+	lines, err := runLogDeduplicationTest(t, snek.NewConfig(snek.WithLogDeduplication(time.Hour)), nil,
+		func(logger *snek.ScopedLogger) {
+			for range 5 {
+				logger.Info().Str("host", "db").Msg("Retrying.")
+			}
+			logger.Warn().Msg("Retrying.")
+			logger.Info().Msg("Connected.")
+			for range 3 {
+				logger.Info().Msg("Retrying.")
+			}
+		})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, []map[string]any{
+		{"level": "info", "host": "db", "message": "Retrying."},
+		{"level": "info", "suppressed": float64(4), "similar": "Retrying.", "message": "Suppressed 4 similar messages."},
+		{"level": "warn", "message": "Retrying."},
+		{"level": "info", "message": "Connected."},
+		{"level": "info", "message": "Retrying."},
+		{"level": "info", "suppressed": float64(2), "similar": "Retrying.", "message": "Suppressed 2 similar messages."},
+	}, lines, "Repeated messages should be suppressed and summarized")
+}
+
+func TestRun_LogDeduplication_DifferentFields(t *testing.T) {
+	allowAllLogLevels(t)
+
+	lines, err := runLogDeduplicationTest(t, snek.NewConfig(snek.WithLogDeduplication(time.Hour), snek.WithLogCaller(true)),
+		nil, func(logger *snek.ScopedLogger) {
+			logger.Info().Str("user", "alice").Msg("Logged in.")
+			logger.Info().Str("user", "bob").Msg("Logged in.")
+			logger.Info().Str("user", "bob").Msg("Logged in.")
+		})
+	require.NoError(t, err, "Run should not return an error")
+	require.Len(t, lines, 3, "Logs with different fields should not be suppressed")
+	assert.Equal(t, "alice", lines[0]["user"], "The first log should be written")
+	assert.Equal(t, "bob", lines[1]["user"], "The log with a different field should be written")
+	assert.Equal(t, float64(1), lines[2]["suppressed"],
+		"The repeated log should be suppressed even though its caller differs")
+}
+
+func TestRun_LogDeduplication_Window(t *testing.T) {
+	allowAllLogLevels(t)
+
+	lines, err := runLogDeduplicationTest(t, snek.NewConfig(snek.WithLogDeduplication(50*time.Millisecond)), nil,
+		func(logger *snek.ScopedLogger) {
+			logger.Info().Msg("Waiting.")
+			logger.Info().Msg("Waiting.")
+			time.Sleep(100 * time.Millisecond)
+			logger.Info().Msg("Waiting.")
+		})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, []map[string]any{
+		{"level": "info", "message": "Waiting."},
+		{"level": "info", "suppressed": float64(1), "similar": "Waiting.", "message": "Suppressed 1 similar messages."},
+		{"level": "info", "message": "Waiting."},
+	}, lines, "Repeated messages should be written again once the window has passed")
+}
+
+func TestRun_LogDeduplication_Fields(t *testing.T) {
+	allowAllLogLevels(t)
+
+	cfg := snek.NewConfig(snek.WithLogDeduplication(time.Hour), snek.WithDryRunFlag(true))
+	lines, err := runLogDeduplicationTest(t, cfg, []string{"--dry-run", "--log-level", "info,db=info"},
+		func(logger *snek.ScopedLogger) {
+			logger.Component("db").Info().Msg("Querying.")
+			logger.Component("db").Info().Msg("Querying.")
+		})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, []map[string]any{
+		{"level": "info", "dry_run": true, "component": "db", "message": "Querying."},
+		{
+			"level":      "info",
+			"dry_run":    true,
+			"component":  "db",
+			"suppressed": float64(1),
+			"similar":    "Querying.",
+			"message":    "Suppressed 1 similar messages.",
+		},
+	}, lines, "The summary should have the fields of the logger")
+}
+
+func TestRun_LogDeduplication_Disabled(t *testing.T) {
+	allowAllLogLevels(t)
+
+	lines, err := runLogDeduplicationTest(t, snek.NewConfig(), nil, func(logger *snek.ScopedLogger) {
+		logger.Info().Msg("Retrying.")
+		logger.Info().Msg("Retrying.")
+	})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Len(t, lines, 2, "Repeated messages should not be suppressed by default")
+}
+
+func TestRun_Config_InvalidLogDeduplication(t *testing.T) {
+	_, err := runLogDeduplicationTest(t, snek.NewConfig(snek.WithLogDeduplication(-time.Second)), nil,
+		func(*snek.ScopedLogger) {})
+	assert.ErrorIs(t, err, snek.ErrLogDeduplicationInvalid, "Run should validate the log deduplication window")
+}
@@ -0,0 +1,140 @@
+package snek
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+)
+
+// logField is a field Run can add to every log line, which is enabled by the
+// Config or by its environment variable.
+type logField struct {
+	// name is the name of the field.
+	name string
+
+	// usage is the help text of the environment variable that enables the
+	// field.
+	usage string
+
+	// environmentVariableName returns the name of the environment variable
+	// that enables the field, without the environment variable prefix.
+	environmentVariableName func(cfg *Config) string
+
+	// enabled returns true if the field is enabled by the Config.
+	enabled func(cfg *Config) bool
+
+	// value returns the value of the field for the executing command, and false
+	// if the field has no value.
+	value func(cmd *Command) (any, bool)
+}
+
+// optionalLogFields are the fields Run can add to every log line. The caller
+// field has no value, as it is added by the logger for every log line.
+var optionalLogFields = []logField{
+	{
+		name:                    "caller",
+		usage:                   "Add a `caller` field with the file and line of the call that logged to every log line.",
+		environmentVariableName: func(cfg *Config) string { return cfg.LogCallerEnvironmentVariableName },
+		enabled:                 func(cfg *Config) bool { return cfg.LogCaller },
+	},
+	{
+		name:                    "command",
+		usage:                   "Add a `command` field with the path of the executing command to every log line.",
+		environmentVariableName: func(cfg *Config) string { return cfg.LogCommandEnvironmentVariableName },
+		enabled:                 func(cfg *Config) bool { return cfg.LogCommand },
+		value: func(cmd *Command) (any, bool) {
+			return cmd.CommandPath(), true
+		},
+	},
+	{
+		name:                    "hostname",
+		usage:                   "Add a `hostname` field with the name of the host to every log line.",
+		environmentVariableName: func(cfg *Config) string { return cfg.LogHostnameEnvironmentVariableName },
+		enabled:                 func(cfg *Config) bool { return cfg.LogHostname },
+		value: func(*Command) (any, bool) {
+			hostname, err := os.Hostname()
+			return hostname, err == nil
+		},
+	},
+	{
+		name:                    "pid",
+		usage:                   "Add a `pid` field with the ID of the process to every log line.",
+		environmentVariableName: func(cfg *Config) string { return cfg.LogPIDEnvironmentVariableName },
+		enabled:                 func(cfg *Config) bool { return cfg.LogPID },
+		value: func(*Command) (any, bool) {
+			return os.Getpid(), true
+		},
+	},
+	{
+		name:                    "version",
+		usage:                   "Add a `version` field with the version of the command to every log line.",
+		environmentVariableName: func(cfg *Config) string { return cfg.LogVersionEnvironmentVariableName },
+		enabled:                 func(cfg *Config) bool { return cfg.LogVersion },
+		value: func(cmd *Command) (any, bool) {
+			version := buildVersion(cmd)
+			return version, version != ""
+		},
+	},
+}
+
+// newLogFields returns the fields added to every log line of the executing
+// command, and whether the caller is added to every log line. A field is added
+// when its environment variable, prefixed with the environment variable
+// prefix, is set to true, or when the variable is not set and the field is
+// enabled by the Config. If the environment variable cannot be parsed as a
+// bool, then an ErrLogFieldEnvironmentVariableInvalid error is returned.
+func newLogFields(cfg *Config, cmd *Command) (map[string]any, bool, error) {
+	fields := map[string]any{}
+	caller := false
+	for _, field := range optionalLogFields {
+		enabled := field.enabled(cfg)
+		envVar := cfg.EnvironmentVariablePrefix + field.environmentVariableName(cfg)
+		if value, ok := os.LookupEnv(envVar); ok {
+			var err error
+			if enabled, err = strconv.ParseBool(value); err != nil {
+				return nil, false, fmt.Errorf("%w: %s=%q: %v", ErrLogFieldEnvironmentVariableInvalid, envVar, value, err)
+			}
+		}
+
+		if !enabled {
+			continue
+		}
+
+		if field.value == nil {
+			caller = true
+			continue
+		}
+
+		if value, ok := field.value(cmd); ok {
+			fields[field.name] = value
+		}
+	}
+	return fields, caller, nil
+}
+
+// logFieldEnvironmentVariables returns the names of the environment variables
+// that enable the log fields, prefixed with the environment variable prefix.
+func logFieldEnvironmentVariables(cfg *Config) []string {
+	names := make([]string, 0, len(optionalLogFields))
+	for _, field := range optionalLogFields {
+		names = append(names, cfg.EnvironmentVariablePrefix+field.environmentVariableName(cfg))
+	}
+	return names
+}
+
+// buildVersion returns the version of the root command set with WithVersion,
+// or the version of the main module from its build information, such as a
+// version installed with `go install`. An empty string is returned if neither
+// is known.
+func buildVersion(cmd *Command) string {
+	if version := cmd.Root().Version; version != "" {
+		return version
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "(devel)" {
+		return ""
+	}
+	return info.Main.Version
+}
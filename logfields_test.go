@@ -0,0 +1,202 @@
+package snek_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+// runLogFieldsTest runs the serve subcommand of a command with the version
+// 1.2.3, which logs with its logger and the logger of a component, and returns
+// the two log lines.
+func runLogFieldsTest(t *testing.T, cfg *snek.Config) ([]map[string]any, error) {
+	t.Helper()
+	var logs bytes.Buffer
+	snek.WithLogOutput(&logs)(cfg)
+	snek.WithDefaultLogFormat("json")(cfg)
+	snek.WithEnvironmentVariablePrefix("APP_")(cfg)
+
+	serve, err := snek.NewCommand(
+		snek.WithUse("serve"),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) {
+			snek.Logger(cmd).Info().Msg("Serving.")
+			snek.Logger(cmd).Component("db").Info().Msg("Connected.")
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	err = snek.Run([]string{"serve"}, cfg,
+		snek.WithUse("app"),
+		snek.WithVersion("1.2.3"),
+		snek.WithSubCommand(serve),
+	)
+
+	var lines []map[string]any
+	for _, line := range decodeLogLines(t, &logs) {
+		if line["message"] != "Logging initialized." {
+			lines = append(lines, line)
+		}
+	}
+	return lines, err
+}
+
+func TestWithLogFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		configurator func(bool) snek.Configurator
+		field        func(*snek.Config) bool
+	}{
+		{name: "caller", configurator: snek.WithLogCaller, field: func(cfg *snek.Config) bool { return cfg.LogCaller }},
+		{name: "command", configurator: snek.WithLogCommand, field: func(cfg *snek.Config) bool { return cfg.LogCommand }},
+		{name: "hostname", configurator: snek.WithLogHostname, field: func(cfg *snek.Config) bool { return cfg.LogHostname }},
+		{name: "pid", configurator: snek.WithLogPID, field: func(cfg *snek.Config) bool { return cfg.LogPID }},
+		{name: "version", configurator: snek.WithLogVersion, field: func(cfg *snek.Config) bool { return cfg.LogVersion }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := snek.NewConfig()
+			require.NotNil(t, cfg, "NewConfig should return a Config")
+			require.False(t, test.field(cfg), "The field should be disabled by default")
+			cfg = snek.NewConfig(test.configurator(true))
+			require.NotNil(t, cfg, "NewConfig should return a Config")
+			assert.True(t, test.field(cfg), "The configurator should enable the field")
+		})
+	}
+}
+
+func TestWithLogFieldEnvironmentVariableNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		configurator func(string) snek.Configurator
+		field        func(*snek.Config) string
+		expected     string
+	}{
+		{
+			name:         "caller",
+			configurator: snek.WithLogCallerEnvironmentVariableName,
+			field:        func(cfg *snek.Config) string { return cfg.LogCallerEnvironmentVariableName },
+			expected:     "LOG_CALLER",
+		},
+		{
+			name:         "command",
+			configurator: snek.WithLogCommandEnvironmentVariableName,
+			field:        func(cfg *snek.Config) string { return cfg.LogCommandEnvironmentVariableName },
+			expected:     "LOG_COMMAND",
+		},
+		{
+			name:         "hostname",
+			configurator: snek.WithLogHostnameEnvironmentVariableName,
+			field:        func(cfg *snek.Config) string { return cfg.LogHostnameEnvironmentVariableName },
+			expected:     "LOG_HOSTNAME",
+		},
+		{
+			name:         "pid",
+			configurator: snek.WithLogPIDEnvironmentVariableName,
+			field:        func(cfg *snek.Config) string { return cfg.LogPIDEnvironmentVariableName },
+			expected:     "LOG_PID",
+		},
+		{
+			name:         "version",
+			configurator: snek.WithLogVersionEnvironmentVariableName,
+			field:        func(cfg *snek.Config) string { return cfg.LogVersionEnvironmentVariableName },
+			expected:     "LOG_VERSION",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := snek.NewConfig()
+			require.NotNil(t, cfg, "NewConfig should return a Config")
+			require.Equal(t, test.expected, test.field(cfg), "The default environment variable name should be set")
+			cfg = snek.NewConfig(test.configurator("FIELD"))
+			require.NotNil(t, cfg, "NewConfig should return a Config")
+			assert.Equal(t, "FIELD", test.field(cfg), "The configurator should set the environment variable name")
+		})
+	}
+}
+
+func TestRun_LogFields(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err, "os.Hostname should not return an error")
+
+	lines, err := runLogFieldsTest(t, snek.NewConfig(
+		snek.WithLogCaller(true),
+		snek.WithLogCommand(true),
+		snek.WithLogHostname(true),
+		snek.WithLogPID(true),
+		snek.WithLogVersion(true),
+	))
+	require.NoError(t, err, "Run should not return an error")
+	require.Len(t, lines, 2, "Both log lines should be written")
+	for _, line := range lines {
+		assert.Regexp(t, `logfields_test\.go:\d+$`, line["caller"], "The caller should be the file and line that logged")
+		assert.Equal(t, "app serve", line["command"], "The command should be the path of the executing command")
+		assert.Equal(t, hostname, line["hostname"], "The hostname should be the name of the host")
+		assert.Equal(t, float64(os.Getpid()), line["pid"], "The pid should be the ID of the process")
+		assert.Equal(t, "1.2.3", line["version"], "The version should be the version of the root command")
+	}
+	assert.Equal(t, "db", lines[1]["component"], "The component should keep its field")
+}
+
+func TestRun_LogFields_Disabled(t *testing.T) {
+	lines, err := runLogFieldsTest(t, snek.NewConfig())
+	require.NoError(t, err, "Run should not return an error")
+	for _, line := range lines {
+		for _, field := range []string{"caller", "command", "hostname", "pid", "version"} {
+			assert.NotContains(t, line, field, "The field should not be added by default")
+		}
+	}
+}
+
+func TestRun_LogFields_EnvironmentVariables(t *testing.T) {
+	t.Setenv("APP_LOG_COMMAND", "true")
+	t.Setenv("APP_LOG_PID", "false")
+
+	var warnings bytes.Buffer
+	lines, err := runLogFieldsTest(t, snek.NewConfig(
+		snek.WithLogPID(true),
+		snek.WithEnvironmentVariableCheck(true),
+		snek.WithLogSink(snek.LogSink{Output: &warnings, Format: "json", Level: "warn"}),
+	))
+	require.NoError(t, err, "Run should not return an error")
+	for _, line := range lines {
+		assert.Equal(t, "app serve", line["command"], "The environment variable should enable the field")
+		assert.NotContains(t, line, "pid", "The environment variable should disable the field")
+	}
+	assert.Empty(t, warnings.String(), "The environment variables should be known")
+}
+
+func TestRun_LogFields_EnvironmentVariableNames(t *testing.T) {
+	t.Setenv("APP_WITH_COMMAND", "true")
+	t.Setenv("APP_LOG_COMMAND", "false")
+
+	var warnings bytes.Buffer
+	lines, err := runLogFieldsTest(t, snek.NewConfig(
+		snek.WithLogCommandEnvironmentVariableName("WITH_COMMAND"),
+		snek.WithEnvironmentVariableCheck(true),
+		snek.WithLogSink(snek.LogSink{Output: &warnings, Format: "json", Level: "warn"}),
+	))
+	require.NoError(t, err, "Run should not return an error")
+	for _, line := range lines {
+		assert.Equal(t, "app serve", line["command"], "The renamed environment variable should enable the field")
+	}
+	assert.NotContains(t, warnings.String(), "APP_WITH_COMMAND", "The renamed environment variable should be known")
+
+	_, err = runLogFieldsTest(t, snek.NewConfig(snek.WithLogPIDEnvironmentVariableName("")))
+	require.ErrorIs(t, err, snek.ErrLogFieldEnvironmentVariableNameEmpty,
+		"Run should return ErrLogFieldEnvironmentVariableNameEmpty for an empty environment variable name")
+	assert.ErrorContains(t, err, "pid", "The error should name the field")
+}
+
+func TestRun_LogFields_InvalidEnvironmentVariable(t *testing.T) {
+	t.Setenv("APP_LOG_HOSTNAME", "sometimes")
+
+	_, err := runLogFieldsTest(t, snek.NewConfig())
+	require.ErrorIs(t, err, snek.ErrLogFieldEnvironmentVariableInvalid, "Run should validate the environment variable")
+	assert.ErrorContains(t, err, `APP_LOG_HOSTNAME="sometimes"`, "The error should name the environment variable")
+}
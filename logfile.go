@@ -0,0 +1,153 @@
+package snek
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogFileRotation configures when a log file is rotated and how many rotated
+// files are kept. Rotated files are renamed with the time they were rotated,
+// such as `app-2006-01-02T15-04-05.000.log`, and kept next to the log file.
+type LogFileRotation struct {
+	// MaxSize is the size in megabytes the log file may reach before it is
+	// rotated.
+	//
+	// If the size is zero, then 100 megabytes is used.
+	MaxSize int
+
+	// Interval is how long the log file is written to before it is rotated,
+	// regardless of its size, such as 24 hours to rotate it daily. The
+	// interval is measured from when the log file was started, across
+	// processes: the time of its most recent rotation, or the time it was
+	// last modified if it has not been rotated yet, so log files written by
+	// short-lived commands are rotated too.
+	//
+	// If the interval is zero, then the log file is only rotated by size.
+	Interval time.Duration
+
+	// MaxAge is the number of days rotated files are kept before they are
+	// removed.
+	//
+	// If the age is zero, then rotated files are not removed because of their
+	// age.
+	MaxAge int
+
+	// MaxBackups is the number of rotated files that are kept.
+	//
+	// If the number is zero, then every rotated file is kept, unless it is
+	// removed because of MaxAge.
+	MaxBackups int
+
+	// Compress is true when rotated files should be compressed with gzip.
+	Compress bool
+}
+
+// DefaultLogFileRotation returns the rotation used for log files by default,
+// which rotates them once they reach 100 megabytes and keeps every rotated
+// file.
+func DefaultLogFileRotation() LogFileRotation {
+	return LogFileRotation{MaxSize: 100}
+}
+
+// logFileBackupTimeFormat is the format of the time in the names of rotated
+// log files.
+const logFileBackupTimeFormat = "2006-01-02T15-04-05.000"
+
+// logFile is a log file that is rotated according to a LogFileRotation.
+type logFile struct {
+	mu       sync.Mutex
+	file     *lumberjack.Logger
+	interval time.Duration
+	rotateAt time.Time
+}
+
+// NewLogFile creates a writer that appends to the file at the path, creating
+// the file and its directories if they do not exist, and rotates the file as
+// configured by the rotation. The file is opened when it is first written to,
+// and must be closed once it is no longer used.
+func NewLogFile(path string, rotation LogFileRotation) io.WriteCloser {
+	return &logFile{
+		file: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    rotation.MaxSize,
+			MaxAge:     rotation.MaxAge,
+			MaxBackups: rotation.MaxBackups,
+			LocalTime:  true,
+			Compress:   rotation.Compress,
+		},
+		interval: rotation.Interval,
+	}
+}
+
+// Write writes the data to the log file, rotating it first if the rotation
+// interval has elapsed since the file was started.
+func (f *logFile) Write(data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.interval > 0 {
+		now := time.Now()
+		if f.rotateAt.IsZero() {
+			f.rotateAt = logFileStarted(f.file.Filename, now).Add(f.interval)
+		}
+
+		if !now.Before(f.rotateAt) {
+			if err := f.file.Rotate(); err != nil {
+				return 0, err
+			}
+			f.rotateAt = now.Add(f.interval)
+		}
+	}
+
+	return f.file.Write(data)
+}
+
+// logFileStarted returns the time the log file at the path was started, which
+// is the time of its most recent rotation recorded in the names of the rotated
+// files next to it, or the time it was last modified if it has not been
+// rotated. If the log file does not exist, then now is returned.
+func logFileStarted(path string, now time.Time) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return now
+	}
+
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return info.ModTime()
+	}
+
+	var started time.Time
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".gz")
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		rotated, err := time.ParseInLocation(logFileBackupTimeFormat, stamp, time.Local)
+		if err == nil && rotated.After(started) {
+			started = rotated
+		}
+	}
+
+	if started.IsZero() || started.After(info.ModTime()) {
+		return info.ModTime()
+	}
+	return started
+}
+
+// Close closes the log file.
+func (f *logFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
@@ -0,0 +1,318 @@
+package snek_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+// backups returns the names of the rotated files next to the log file.
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(strings.TrimSuffix(path, ".log") + "-*")
+	require.NoError(t, err, "Glob should not return an error")
+	return matches
+}
+
+func TestDefaultLogFileRotation(t *testing.T) {
+	assert.Equal(t, snek.LogFileRotation{MaxSize: 100}, snek.DefaultLogFileRotation(),
+		"The default rotation should rotate at 100 megabytes")
+}
+
+func TestNewLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	file := snek.NewLogFile(path, snek.DefaultLogFileRotation())
+	_, err := io.WriteString(file, "first\n")
+	require.NoError(t, err, "Write should not return an error")
+	require.NoError(t, file.Close(), "Close should not return an error")
+
+	file = snek.NewLogFile(path, snek.DefaultLogFileRotation())
+	_, err = io.WriteString(file, "second\n")
+	require.NoError(t, err, "Write should not return an error")
+	require.NoError(t, file.Close(), "Close should not return an error")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err, "ReadFile should not return an error")
+	assert.Equal(t, "first\nsecond\n", string(data), "The log file should be appended to")
+	assert.Empty(t, backups(t, path), "The log file should not be rotated")
+}
+
+func TestNewLogFile_MaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file := snek.NewLogFile(path, snek.LogFileRotation{MaxSize: 1, MaxBackups: 1})
+	defer file.Close()
+
+	line := strings.Repeat("x", 1023) + "\n"
+	for range 3 * 1024 {
+		_, err := io.WriteString(file, line)
+		require.NoError(t, err, "Write should not return an error")
+	}
+
+	info, err := os.Stat(path)
+	require.NoError(t, err, "Stat should not return an error")
+	assert.LessOrEqual(t, info.Size(), int64(1024*1024), "The log file should not exceed the maximum size")
+	assert.Eventually(t, func() bool { return len(backups(t, path)) == 1 }, time.Second, 10*time.Millisecond,
+		"Only the maximum number of backups should be kept")
+}
+
+func TestNewLogFile_Interval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file := snek.NewLogFile(path, snek.LogFileRotation{Interval: 20 * time.Millisecond})
+	defer file.Close()
+
+	_, err := io.WriteString(file, "first\n")
+	require.NoError(t, err, "Write should not return an error")
+	_, err = io.WriteString(file, "second\n")
+	require.NoError(t, err, "Write should not return an error")
+	assert.Empty(t, backups(t, path), "The log file should not be rotated before the interval")
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = io.WriteString(file, "third\n")
+	require.NoError(t, err, "Write should not return an error")
+	require.Len(t, backups(t, path), 1, "The log file should be rotated once the interval has elapsed")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err, "ReadFile should not return an error")
+	assert.Equal(t, "third\n", string(data), "The log file should only contain lines written after the rotation")
+}
+
+func TestNewLogFile_Interval_Reopened(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644), "WriteFile should not return an error")
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old), "Chtimes should not return an error")
+
+	file := snek.NewLogFile(path, snek.LogFileRotation{Interval: time.Hour})
+	_, err := io.WriteString(file, "new\n")
+	require.NoError(t, err, "Write should not return an error")
+	require.NoError(t, file.Close(), "Close should not return an error")
+
+	require.Len(t, backups(t, path), 1, "A reopened log file older than the interval should be rotated")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err, "ReadFile should not return an error")
+	assert.Equal(t, "new\n", string(data), "The log file should only contain lines written after the rotation")
+
+	file = snek.NewLogFile(path, snek.LogFileRotation{Interval: time.Hour})
+	_, err = io.WriteString(file, "newer\n")
+	require.NoError(t, err, "Write should not return an error")
+	require.NoError(t, file.Close(), "Close should not return an error")
+	assert.Len(t, backups(t, path), 1, "A reopened log file younger than the interval should not be rotated")
+}
+
+func TestNewLogFile_Interval_RotatedBefore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rotated := time.Now().Add(-2 * time.Hour).Format("2006-01-02T15-04-05.000")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-"+rotated+".log"), []byte("older\n"), 0o644),
+		"WriteFile should not return an error")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644), "WriteFile should not return an error")
+
+	file := snek.NewLogFile(path, snek.LogFileRotation{Interval: time.Hour})
+	_, err := io.WriteString(file, "new\n")
+	require.NoError(t, err, "Write should not return an error")
+	require.NoError(t, file.Close(), "Close should not return an error")
+
+	assert.Len(t, backups(t, path), 2,
+		"A log file started by a rotation older than the interval should be rotated, even if it was recently modified")
+}
+
+func TestNewLogFile_Compress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file := snek.NewLogFile(path, snek.LogFileRotation{Interval: time.Millisecond, Compress: true})
+	defer file.Close()
+
+	_, err := io.WriteString(file, "first\n")
+	require.NoError(t, err, "Write should not return an error")
+	time.Sleep(5 * time.Millisecond)
+	_, err = io.WriteString(file, "second\n")
+	require.NoError(t, err, "Write should not return an error")
+
+	assert.Eventually(t, func() bool {
+		rotated := backups(t, path)
+		return len(rotated) == 1 && strings.HasSuffix(rotated[0], ".log.gz")
+	}, time.Second, 10*time.Millisecond, "The rotated file should be compressed")
+}
+
+func TestWithLogFile(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Empty(t, cfg.LogFile, "The default log file should be empty")
+	cfg = snek.NewConfig(snek.WithLogFile("app.log"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "app.log", cfg.LogFile, "LogFile should be app.log")
+}
+
+func TestWithLogFileEnvironmentVariableName(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "LOG_FILE", cfg.LogFileEnvironmentVariableName, "The default log file environment variable name should be LOG_FILE")
+	cfg = snek.NewConfig(snek.WithLogFileEnvironmentVariableName("LOGS"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "LOGS", cfg.LogFileEnvironmentVariableName, "LogFileEnvironmentVariableName should be LOGS")
+}
+
+func TestWithLogFileRotation(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, snek.DefaultLogFileRotation(), cfg.LogFileRotation, "The default rotation should be DefaultLogFileRotation")
+	rotation := snek.LogFileRotation{MaxSize: 10, Interval: time.Hour, MaxAge: 7, MaxBackups: 3, Compress: true}
+	cfg = snek.NewConfig(snek.WithLogFileRotation(rotation))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, rotation, cfg.LogFileRotation, "LogFileRotation should be set")
+}
+
+func TestWithLogFileCommandLineVariableHelp(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.NotEmpty(t, cfg.LogFileCommandLineVariableHelp, "The default log file command line variable help should be set")
+	cfg = snek.NewConfig(snek.WithLogFileCommandLineVariableHelp("Where to log."))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "Where to log.", cfg.LogFileCommandLineVariableHelp, "LogFileCommandLineVariableHelp should be set")
+}
+
+func TestWithLogFileCommandLineVariableLongName(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "log-file", cfg.LogFileCommandLineVariableLongName,
+		"The default log file command line variable long name should be log-file")
+	cfg = snek.NewConfig(snek.WithLogFileCommandLineVariableLongName("logs"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "logs", cfg.LogFileCommandLineVariableLongName, "LogFileCommandLineVariableLongName should be logs")
+}
+
+func TestWithLogFileFlag(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.LogFileFlag, "LogFileFlag should be false")
+	cfg = snek.NewConfig(snek.WithLogFileFlag(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.LogFileFlag, "LogFileFlag should be true")
+}
+
+func TestRun_Config_InvalidLogFileCommandLineVariableLongName(t *testing.T) {
+	cfg := snek.NewConfig(snek.WithLogFileFlag(true), snek.WithLogFileCommandLineVariableLongName(""))
+	err := snek.Run(nil, cfg)
+	assert.ErrorIs(t, err, snek.ErrLogFileCommandLineVariableNameEmpty,
+		"Run should return an error if the log file command line variable name is empty")
+}
+
+func TestRun_Config_InvalidLogFileEnvironmentVariableName(t *testing.T) {
+	cfg := snek.NewConfig(snek.WithLogFileEnvironmentVariableName(""))
+	err := snek.Run(nil, cfg)
+	assert.ErrorIs(t, err, snek.ErrLogFileEnvironmentVariableNameEmpty,
+		"Run should return an error if the log file environment variable name is empty")
+}
+
+func TestRun_Config_InvalidLogFileRotation(t *testing.T) {
+	cfg := snek.NewConfig(snek.WithLogFileRotation(snek.LogFileRotation{MaxBackups: -1}))
+	err := snek.Run(nil, cfg)
+	assert.ErrorIs(t, err, snek.ErrLogFileRotationInvalid,
+		"Run should return an error if the log file rotation is invalid")
+}
+
+func runLogFileTest(t *testing.T, cfg *snek.Config, args []string) string {
+	t.Helper()
+	var output strings.Builder
+	snek.WithLogOutput(&output)(cfg)
+	err := snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) {
+			snek.Logger(cmd).Warn().Str("key", "value").Msg("Written to the file.")
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+	return output.String()
+}
+
+func TestRun_LogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	output := runLogFileTest(t, snek.NewConfig(snek.WithLogFileFlag(true)), []string{"--log-file", path})
+	assert.Empty(t, output, "Nothing should be logged to the log output")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err, "ReadFile should not return an error")
+	assert.Contains(t, string(data), "WRN Written to the file. key=value", "The log line should be written to the file")
+	assert.NotContains(t, string(data), "\x1b[", "The log file should not contain colors")
+}
+
+func TestRun_LogFile_Sources(t *testing.T) {
+	dir := t.TempDir()
+	configured := filepath.Join(dir, "configured.log")
+	runLogFileTest(t, snek.NewConfig(snek.WithLogFile(configured), snek.WithDefaultLogFormat("json")), nil)
+	data, err := os.ReadFile(configured)
+	require.NoError(t, err, "ReadFile should not return an error")
+	assert.Contains(t, string(data), `"message":"Written to the file."`, "The configured log file should be written to")
+
+	environment := filepath.Join(dir, "environment.log")
+	t.Setenv("APP_LOG_FILE", environment)
+	runLogFileTest(t, snek.NewConfig(snek.WithLogFile(configured), snek.WithEnvironmentVariablePrefix("APP_")), nil)
+	_, err = os.Stat(environment)
+	assert.NoError(t, err, "The environment variable should override the configured log file")
+
+	output := runLogFileTest(t, snek.NewConfig(snek.WithLogFileFlag(true), snek.WithEnvironmentVariablePrefix("APP_")),
+		[]string{"--log-file", ""})
+	assert.Contains(t, output, "Written to the file.", "An empty flag should write logs to the log output")
+}
+
+func TestRun_LogFileFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	err := snek.Run([]string{"--log-file", path}, snek.NewConfig(snek.WithLogOutput(io.Discard)), snek.WithUse("app"))
+	assert.Error(t, err, "The log file flag should not be added without WithLogFileFlag")
+
+	cfg := snek.NewConfig(snek.WithLogFileFlag(true), snek.WithLogFileCommandLineVariableLongName("logs"))
+	runLogFileTest(t, cfg, []string{"--logs", path})
+	data, err := os.ReadFile(path)
+	require.NoError(t, err, "ReadFile should not return an error")
+	assert.Contains(t, string(data), "Written to the file.", "The renamed flag should set the log file")
+}
+
+func TestRun_LogFileFlag_Help(t *testing.T) {
+	var help bytes.Buffer
+	cfg := snek.NewConfig(
+		snek.WithLogOutput(io.Discard),
+		snek.WithLogFileFlag(true),
+		snek.WithLogFileCommandLineVariableHelp("Where to log."),
+	)
+	err := snek.Run([]string{"--help"}, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+		func(cmd *cobra.Command) error {
+			cmd.SetOut(&help)
+			return nil
+		},
+	)
+	require.NoError(t, err, "Run should not return an error")
+	assert.Contains(t, help.String(), "Where to log.", "The help of the log file flag should be configurable")
+}
+
+func TestRun_LogFile_EnvironmentVariableCheck(t *testing.T) {
+	t.Setenv("APP_LOG_FILE", "")
+
+	var warnings bytes.Buffer
+	runLogFileTest(t, snek.NewConfig(
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithEnvironmentVariableCheck(true),
+		snek.WithLogSink(snek.LogSink{Output: &warnings, Format: "json", Level: "warn"}),
+	), nil)
+	assert.NotContains(t, warnings.String(), "APP_LOG_FILE",
+		"The log file environment variable should be known without the log file flag")
+}
+
+func TestRun_LogFileFlag_Duplicate(t *testing.T) {
+	var path string
+	err := snek.Run(nil, snek.NewConfig(snek.WithLogOutput(io.Discard), snek.WithLogFileFlag(true)),
+		snek.WithUse("app"),
+		snek.WithPersistentFlag(snek.WithStringVar(&path, "log-file", "", "The file to write logs to.")),
+	)
+	assert.ErrorIs(t, err, snek.ErrFlagDuplicate, "Run should return ErrFlagDuplicate if the log file flag is in use")
+}
@@ -0,0 +1,149 @@
+package snek
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// LogFormatLogfmt is the logfmt log format that prints log lines as
+	// space separated `key=value` pairs.
+	LogFormatLogfmt = "logfmt"
+
+	// LogFormatShort is the short log format that prints log lines in a compact
+	// human-readable format without timestamps.
+	LogFormatShort = "short"
+)
+
+// LogFormat creates the writer logs in a format are written to out through.
+// Each log line is written to the returned writer as a JSON object, which the
+// writer encodes in the format. When color is false, the writer must not write
+// colors, such as when logs are written to a file.
+type LogFormat func(out io.Writer, color bool) io.Writer
+
+// DefaultLogFormats returns the log formats known to snek by default, keyed by
+// name, which are `formatted`, `json`, `logfmt`, and `short`.
+func DefaultLogFormats() map[string]LogFormat {
+	return map[string]LogFormat{
+		LogFormatFormatted: newFormattedLogWriter,
+		LogFormatJson:      newJSONLogWriter,
+		LogFormatLogfmt:    newLogfmtLogWriter,
+		LogFormatShort:     newShortLogWriter,
+	}
+}
+
+// logFormatNames returns the names of the log formats, sorted by name.
+func logFormatNames(formats map[string]LogFormat) []string {
+	return slices.Sorted(maps.Keys(formats))
+}
+
+// newFormattedLogWriter creates a writer for the formatted log format.
+func newFormattedLogWriter(out io.Writer, color bool) io.Writer {
+	return zerolog.ConsoleWriter{
+		Out:        out,
+		NoColor:    !color,
+		TimeFormat: time.DateTime,
+	}
+}
+
+// newJSONLogWriter creates a writer for the JSON log format, which writes the
+// log lines as they are.
+func newJSONLogWriter(out io.Writer, _ bool) io.Writer {
+	return out
+}
+
+// newShortLogWriter creates a writer for the short log format.
+func newShortLogWriter(out io.Writer, color bool) io.Writer {
+	return zerolog.ConsoleWriter{
+		Out:          out,
+		NoColor:      !color,
+		PartsExclude: []string{zerolog.TimestampFieldName},
+	}
+}
+
+// logfmtWriter is a writer for the logfmt log format.
+type logfmtWriter struct {
+	out io.Writer
+}
+
+// newLogfmtLogWriter creates a writer for the logfmt log format. Logfmt has no
+// colors, so color is ignored.
+func newLogfmtLogWriter(out io.Writer, _ bool) io.Writer {
+	return &logfmtWriter{out: out}
+}
+
+// Write writes the JSON log line as logfmt. The time, level and message are
+// written first, followed by the other fields sorted by name.
+func (w *logfmtWriter) Write(p []byte) (int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return 0, err
+	}
+
+	first := []string{zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName}
+	keys := slices.DeleteFunc(slices.Sorted(maps.Keys(fields)), func(key string) bool {
+		return slices.Contains(first, key)
+	})
+
+	var line strings.Builder
+	for _, key := range append(first, keys...) {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(key)
+		line.WriteByte('=')
+		line.WriteString(logfmtValue(value))
+	}
+	line.WriteByte('\n')
+
+	if _, err := io.WriteString(w.out, line.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logfmtValue returns the value encoded for logfmt. Strings are quoted when
+// they are empty or contain spaces, quotes, equals signs or control
+// characters, and objects and arrays are encoded as quoted JSON.
+func logfmtValue(value any) string {
+	var s string
+	switch value := value.(type) {
+	case string:
+		s = value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	case nil:
+		return "null"
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return strconv.Quote(err.Error())
+		}
+		s = string(data)
+	}
+
+	if s == "" || strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '=' || r == '\\' || r == 0x7f
+	}) {
+		return strconv.Quote(s)
+	}
+	return s
+}
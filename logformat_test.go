@@ -0,0 +1,109 @@
+package snek_test
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func runLogFormatTest(t *testing.T, cfg *snek.Config, format string) string {
+	t.Helper()
+	var logs bytes.Buffer
+	snek.WithLogOutput(&logs)(cfg)
+	err := snek.Run([]string{"--log-format", format}, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) {
+			snek.Logger(cmd).Warn().
+				Str("name", "db primary").
+				Int("count", 3).
+				Bool("ok", true).
+				Str("empty", "").
+				Strs("tags", []string{"a", "b"}).
+				Msg("Connection lost.")
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2, "Two lines should be logged")
+	return lines[1]
+}
+
+func TestDefaultLogFormats(t *testing.T) {
+	formats := snek.DefaultLogFormats()
+	var names []string
+	for name := range formats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	assert.Equal(t, []string{"formatted", "json", "logfmt", "short"}, names, "The default log formats should be known")
+}
+
+func TestWithLogFormat(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Len(t, cfg.LogFormats, 4, "The default log formats should be set")
+
+	cfg = snek.NewConfig(snek.WithLogFormat("custom", func(out io.Writer, color bool) io.Writer { return out }))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Len(t, cfg.LogFormats, 5, "WithLogFormat should add the format")
+	assert.NotNil(t, cfg.LogFormats["custom"], "WithLogFormat should add the format by name")
+
+	cfg = &snek.Config{}
+	snek.WithLogFormat("custom", func(out io.Writer, color bool) io.Writer { return out })(cfg)
+	assert.Len(t, cfg.LogFormats, 5, "WithLogFormat should add the default formats to an empty config")
+}
+
+func TestRun_LogFormat_Logfmt(t *testing.T) {
+	line := runLogFormatTest(t, snek.NewConfig(), "logfmt")
+	assert.Regexp(t, regexp.MustCompile(`^time=\S+ level=warn message="Connection lost\." `+
+		`count=3 empty="" name="db primary" ok=true tags="\[\\"a\\",\\"b\\"\]"$`), line,
+		"The log line should be written as logfmt")
+}
+
+func TestRun_LogFormat_Short(t *testing.T) {
+	line := runLogFormatTest(t, snek.NewConfig(), "short")
+	assert.Regexp(t, `^\S*WRN\S* \S*Connection lost\.`, line, "The log line should start with the level")
+	assert.NotRegexp(t, `\d{2}:\d{2}`, line, "The log line should not have a timestamp")
+	assert.Contains(t, line, "name=", "The log line should have the fields")
+}
+
+func TestRun_LogFormat_Custom(t *testing.T) {
+	cfg := snek.NewConfig(snek.WithLogFormat("upper", func(out io.Writer, color bool) io.Writer {
+		return writerFunc(func(p []byte) (int, error) {
+			_, err := out.Write(bytes.ToUpper(p))
+			return len(p), err
+		})
+	}))
+	line := runLogFormatTest(t, cfg, "upper")
+	assert.Contains(t, line, `"MESSAGE":"CONNECTION LOST."`, "The registered log format should be used")
+}
+
+func TestRun_Config_InvalidLogFormat_Registered(t *testing.T) {
+	err := snek.Run(nil, snek.NewConfig(snek.WithDefaultLogFormat("upper")))
+	assert.ErrorIs(t, err, snek.ErrLogFormatInvalid, "Run should return an error for a format that is not registered")
+
+	cfg := snek.NewConfig(
+		snek.WithLogOutput(io.Discard),
+		snek.WithDefaultLogFormat("upper"),
+		snek.WithLogFormat("upper", func(out io.Writer, color bool) io.Writer { return out }),
+	)
+	err = snek.Run(nil, cfg, snek.WithRun(func(*cobra.Command, []string) {}))
+	assert.NoError(t, err, "Run should accept a registered default format")
+}
+
+// writerFunc is an io.Writer implemented by a function.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -1,11 +1,18 @@
 package snek
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
 )
 
 const (
@@ -17,55 +24,350 @@ const (
 	LogFormatJson = "json"
 )
 
-// setupLogging sets the logging level and format to the specified level and
-// format. If either the level or format is invalid, then an ErrorLogFormatInvalid
-// or ErrorLogLevelInvalid error is returned.
+// LogSink is a destination logs are written to in addition to the log output,
+// with its own format and minimum level, such as JSON logs written to a file
+// at the debug level while human-readable logs are written to the terminal at
+// the info level.
+type LogSink struct {
+	// Output is the writer logs are written to.
+	Output io.Writer
+
+	// Format is the format logs are written in.
+	//
+	// Valid values are the names of the configured log formats, such as
+	// `formatted`, `json`, `logfmt`, and `short`. If the format is an empty
+	// string, then the format of the log output is used.
+	Format string
+
+	// Level is the minimum level of the logs written to the sink.
+	//
+	// Valid values are `debug`, `error`, `fatal`, `info`, `panic`, `trace`, and
+	// `warn`. If the level is an empty string, then the level of the log
+	// output is used.
+	Level string
+}
+
+// logSink is a log sink with its format and level resolved, whether logs in
+// the formatted format are written to it with colors, and whether its level is
+// the level of the log output.
+type logSink struct {
+	LogSink
+	color     bool
+	inherited bool
+}
+
+// newLogSinks returns the primary log sink, which writes to out with the format
+// and level set by the log flags, followed by the configured log sinks. Logs
+// are written to the configured sinks with colors only when their output is a
+// terminal.
+func newLogSinks(level, format string, out io.Writer, color bool, sinks []LogSink) []logSink {
+	resolved := []logSink{{LogSink: LogSink{Output: out, Format: format, Level: level}, color: color, inherited: true}}
+	for _, sink := range sinks {
+		inherited := sink.Level == ""
+		if sink.Format == "" {
+			sink.Format = format
+		}
+		if inherited {
+			sink.Level = level
+		}
+		resolved = append(resolved, logSink{LogSink: sink, color: isTerminal(sink.Output), inherited: inherited})
+	}
+	return resolved
+}
+
+// ScopedLogger is the logger of a command tree executed by Run. It logs like the
+// zerolog logger it embeds, and creates the loggers of the components of the
+// command, such as its database or HTTP client.
+type ScopedLogger struct {
+	*zerolog.Logger
+	components    map[string]*zerolog.Logger
+	deduplicators []*deduplicatingWriter
+
+	// caller is true when the caller is added to every log line, and
+	// uncalled is the logger without the caller, which log/slog records are
+	// written to with the caller of the record instead.
+	caller   bool
+	uncalled *zerolog.Logger
+}
+
+// Component returns the logger of the named component, which adds a
+// `component` field to every log line. If a level is set for the component by
+// the log level flag, such as `db=debug` in `--log-level=info,db=debug`, then
+// the component logs at that level instead of the level of the log output.
+func (l *ScopedLogger) Component(name string) *zerolog.Logger {
+	if logger, ok := l.components[name]; ok {
+		return logger
+	}
+	logger := l.With().Str("component", name).Logger()
+	return &logger
+}
+
+// flush writes the summaries of the logs suppressed by deduplication.
+func (l *ScopedLogger) flush() {
+	for _, deduplicator := range l.deduplicators {
+		deduplicator.flush()
+	}
+}
+
+// loggerOptions are the options of the logger created by newLogger.
+type loggerOptions struct {
+	// caller is true when the caller is added to every log line.
+	caller bool
+
+	// components are the levels of the components of the logger.
+	components map[string]zerolog.Level
+
+	// deduplication is the window repeated logs are suppressed in, or zero
+	// when logs are not deduplicated.
+	deduplication time.Duration
+
+	// fields are added to every log line.
+	fields map[string]any
+
+	// formats are the log formats by name.
+	formats map[string]LogFormat
+
+	// sampler samples the logs, or is nil when logs are not sampled.
+	sampler zerolog.Sampler
+}
+
+// newLogger creates a logger that writes to the sinks, the first of which is
+// the primary sink set by the log flags, in their formats. Each component with
+// a level writes to the primary sink, and to the sinks without a level of
+// their own, at its level instead. The lowest level of the sinks and
+// components is also returned. If the format of a sink is not one of the
+// formats or its level is invalid, then an ErrLogFormatInvalid or
+// ErrLogLevelInvalid error is returned.
 //
-// If the level is debug, then a debug log line is written confirming that debug
-// logging is enabled.
-func setupLogging(level, format string, out io.Writer) error {
-	if err := setupLoggingFormat(format, out); err != nil {
-		return err
+// If the level of any sink is debug or lower, then a debug log line is written
+// confirming that debug logging is enabled.
+func newLogger(sinks []logSink, opts loggerOptions) (*ScopedLogger, zerolog.Level, error) {
+	scoped := &ScopedLogger{components: make(map[string]*zerolog.Logger, len(opts.components)), caller: opts.caller}
+	uncalled, lowest, err := scoped.newLogger(sinks, opts, opts.fields)
+	if err != nil {
+		return nil, lowest, err
 	}
+	logger := withCaller(uncalled, opts.caller)
+	scoped.Logger, scoped.uncalled = logger, uncalled
 
-	if err := setupLoggingLevel(level); err != nil {
-		return err
+	for name, level := range opts.components {
+		componentSinks := slices.Clone(sinks)
+		for i := range componentSinks {
+			if componentSinks[i].inherited {
+				componentSinks[i].Level = level.String()
+			}
+		}
+
+		fields := maps.Clone(opts.fields)
+		if fields == nil {
+			fields = map[string]any{}
+		}
+		fields["component"] = name
+		component, componentLowest, err := scoped.newLogger(componentSinks, opts, fields)
+		if err != nil {
+			return nil, lowest, fmt.Errorf("%w: component %q", err, name)
+		}
+		scoped.components[name] = withCaller(component, opts.caller)
+		lowest = min(lowest, componentLowest)
 	}
 
-	log.Info().Str("level", level).Str("format", format).Msg("Logging initialized.")
-	return nil
+	logger.Debug().Msg("Debug logging enabled.")
+
+	event := logger.Info().Str("level", sinks[0].Level).Str("format", sinks[0].Format)
+	if len(opts.components) > 0 {
+		levels := zerolog.Dict()
+		for _, name := range slices.Sorted(maps.Keys(opts.components)) {
+			levels.Str(name, opts.components[name].String())
+		}
+		event.Dict("components", levels)
+	}
+	event.Msg("Logging initialized.")
+	return scoped, lowest, nil
 }
 
-// setupLoggingFormat sets the logging format to the specified format. If the
-// format is invalid, then an ErrorLogFormatInvalid error is returned.
-func setupLoggingFormat(format string, out io.Writer) error {
-	switch format {
-	case LogFormatFormatted:
-		log.Logger = log.Output(zerolog.ConsoleWriter{
-			Out:        out,
-			TimeFormat: time.DateTime,
-		})
-	case LogFormatJson:
-		log.Logger = log.Output(out)
-	default:
-		log.Error().Str("format", format).Msg("Invalid log format")
-		return ErrLogFormatInvalid
+// newLogger creates a logger that writes to the sinks with the fields, sampled
+// and deduplicated as set by the options, and returns it with its level. The
+// caller is not added by the logger, see withCaller.
+func (l *ScopedLogger) newLogger(sinks []logSink, opts loggerOptions, fields map[string]any) (*zerolog.Logger, zerolog.Level, error) {
+	writer, lowest, err := newLogWriter(sinks, opts.formats)
+	if err != nil {
+		return nil, lowest, err
+	}
+
+	if opts.deduplication > 0 {
+		deduplicator := newDeduplicatingWriter(writer, opts.deduplication, fields)
+		l.deduplicators = append(l.deduplicators, deduplicator)
+		writer = deduplicator
 	}
-	return nil
+
+	logger := zerolog.New(writer).Level(lowest).With().Timestamp().Fields(fields).Logger()
+	if opts.sampler != nil {
+		logger = logger.Sample(opts.sampler)
+	}
+	return &logger, lowest, nil
+}
+
+// withCaller returns the logger with the caller added to every log line when
+// caller is true, or the logger itself otherwise.
+func withCaller(logger *zerolog.Logger, caller bool) *zerolog.Logger {
+	if !caller {
+		return logger
+	}
+	called := logger.With().Caller().Logger()
+	return &called
+}
+
+// newLogWriter returns a writer that writes logs to the sinks in their formats,
+// filtered by their levels, and the lowest level of the sinks. If the format of
+// a sink is not one of the formats or its level is invalid, then an
+// ErrLogFormatInvalid or ErrLogLevelInvalid error is returned.
+func newLogWriter(sinks []logSink, formats map[string]LogFormat) (zerolog.LevelWriter, zerolog.Level, error) {
+	writers := make([]io.Writer, 0, len(sinks))
+	lowest := zerolog.Disabled
+	for i, sink := range sinks {
+		writer, err := newLogSinkWriter(sink, formats)
+		if err != nil {
+			if i > 0 {
+				return nil, lowest, fmt.Errorf("%w: log sink %d", err, i)
+			}
+			return nil, lowest, err
+		}
+
+		level, err := parseLogLevel(sink.Level)
+		if err != nil {
+			if i > 0 {
+				return nil, lowest, fmt.Errorf("%w: log sink %d", err, i)
+			}
+			return nil, lowest, err
+		}
+
+		lowest = min(lowest, level)
+		if len(sinks) > 1 {
+			writer = &zerolog.FilteredLevelWriter{Writer: zerolog.LevelWriterAdapter{Writer: writer}, Level: level}
+		}
+		writers = append(writers, writer)
+	}
+
+	if len(writers) > 1 {
+		return zerolog.MultiLevelWriter(writers...), lowest, nil
+	}
+	if writer, ok := writers[0].(zerolog.LevelWriter); ok {
+		return writer, lowest, nil
+	}
+	return zerolog.LevelWriterAdapter{Writer: writers[0]}, lowest, nil
 }
 
-// setupLoggingLevel sets the logging level to the specified level. If the level
-// is invalid, then an ErrorLogLevelInvalid error is returned.
+// Logger returns the logger of the command, which Run creates for each
+// execution from the log flags and configuration, so concurrent calls to Run
+// do not share a logger. The logger is also attached to the context of the
+// executing command, where it can be retrieved with zerolog.Ctx.
 //
-// If the level is debug, then a debug log line is written confirming that debug
-// logging is enabled.
-func setupLoggingLevel(level string) error {
-	actual, err := zerolog.ParseLevel(level)
+// If the command was not executed by Run, or logging has not been set up yet,
+// then a logger that writes to the global zerolog logger is returned.
+func Logger(cmd *Command) *ScopedLogger {
+	if ext := lookupExtensions(cmd.Root()); ext != nil {
+		ext.mu.Lock()
+		defer ext.mu.Unlock()
+		if ext.logger != nil {
+			return ext.logger
+		}
+	}
+	return &ScopedLogger{Logger: &log.Logger}
+}
+
+// setLogger sets the logger of the command tree returned by Logger, and
+// attaches it to the context of the executing command.
+func setLogger(cmd *Command, logger *ScopedLogger) {
+	ext := extensionsFor(cmd.Root())
+	ext.mu.Lock()
+	ext.logger = logger
+	ext.mu.Unlock()
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd.SetContext(logger.WithContext(ctx))
+}
+
+// newLogSinkWriter returns a writer that writes logs to the output of the sink
+// in its format. If the format is not one of the formats, then an
+// ErrLogFormatInvalid error is returned.
+func newLogSinkWriter(sink logSink, formats map[string]LogFormat) (io.Writer, error) {
+	format := formats[sink.Format]
+	if format == nil {
+		log.Error().Str("format", sink.Format).Strs("formats", logFormatNames(formats)).Msg("Invalid log format")
+		return nil, ErrLogFormatInvalid
+	}
+	return format(sink.Output, sink.color), nil
+}
+
+// parseLogLevel parses the log level. If the level is invalid, then an
+// ErrLogLevelInvalid error is returned.
+func parseLogLevel(level string) (zerolog.Level, error) {
+	parsed, err := zerolog.ParseLevel(level)
 	if err != nil {
 		log.Error().Err(err).Msg("Error parsing log level")
-		return ErrLogLevelInvalid
+		return zerolog.NoLevel, ErrLogLevelInvalid
 	}
-	zerolog.SetGlobalLevel(actual)
-	log.Debug().Msg("Debug logging enabled.")
-	return nil
+	return parsed, nil
+}
+
+// parseLogLevels parses a log level specification, which is a comma separated
+// list of a level and levels of components, such as `info,db=debug,http=warn`.
+// If the specification has no level, such as `db=debug`, then the fallback
+// level is returned. If a level is invalid, a component has no name, or more
+// than one level is specified for the log output or a component, then an
+// ErrLogLevelInvalid error is returned.
+func parseLogLevels(spec, fallback string) (string, map[string]zerolog.Level, error) {
+	level := ""
+	components := map[string]zerolog.Level{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		name, value, ok := strings.Cut(part, "=")
+		if part == "" {
+			continue
+		}
+		if !ok {
+			if level != "" {
+				log.Error().Str("levels", spec).Msg("Log level is specified more than once")
+				return "", nil, fmt.Errorf("%w: level is specified more than once in %q", ErrLogLevelInvalid, spec)
+			}
+			if _, err := parseLogLevel(part); err != nil {
+				return "", nil, fmt.Errorf("%w: %q", err, part)
+			}
+			level = part
+			continue
+		}
+
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if name == "" {
+			log.Error().Str("levels", spec).Msg("Log level component name is empty")
+			return "", nil, fmt.Errorf("%w: component name is empty in %q", ErrLogLevelInvalid, spec)
+		}
+		if _, ok := components[name]; ok {
+			log.Error().Str("levels", spec).Str("component", name).Msg("Log level component is specified more than once")
+			return "", nil, fmt.Errorf("%w: component %q is specified more than once", ErrLogLevelInvalid, name)
+		}
+		parsed, err := parseLogLevel(value)
+		if value == "" {
+			log.Error().Str("levels", spec).Str("component", name).Msg("Log level of component is empty")
+			err = ErrLogLevelInvalid
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %q for component %q", err, value, name)
+		}
+		components[name] = parsed
+	}
+
+	if level == "" {
+		level = fallback
+	}
+	return level, components, nil
+}
+
+// isTerminal returns true if the writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
 }
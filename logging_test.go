@@ -0,0 +1,362 @@
+package snek_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func runLogSinkTest(t *testing.T, cfg *snek.Config, args []string) error {
+	t.Helper()
+	return snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) {
+			snek.Logger(cmd).Debug().Msg("debug test")
+			snek.Logger(cmd).Info().Msg("info test")
+			snek.Logger(cmd).Warn().Msg("warn test")
+		}),
+	)
+}
+
+func TestWithLogSink(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Empty(t, cfg.LogSinks, "The default log sinks should be empty")
+
+	first := snek.LogSink{Output: io.Discard, Format: "json", Level: "debug"}
+	second := snek.LogSink{Output: io.Discard}
+	cfg = snek.NewConfig(snek.WithLogSink(first), snek.WithLogSink(second))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, []snek.LogSink{first, second}, cfg.LogSinks, "WithLogSink should add the sinks")
+}
+
+func TestRun_Config_InvalidLogSink(t *testing.T) {
+	tests := []struct {
+		name     string
+		sink     snek.LogSink
+		expected error
+	}{
+		{name: "output", sink: snek.LogSink{}, expected: snek.ErrLogOutputEmpty},
+		{name: "format", sink: snek.LogSink{Output: io.Discard, Format: "xml"}, expected: snek.ErrLogFormatInvalid},
+		{name: "level", sink: snek.LogSink{Output: io.Discard, Level: "loud"}, expected: snek.ErrLogLevelInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := snek.NewConfig(snek.WithLogOutput(io.Discard), snek.WithLogSink(tt.sink))
+			err := snek.Run(nil, cfg)
+			assert.ErrorIs(t, err, tt.expected, "Run should return an error for an invalid log sink")
+		})
+	}
+}
+
+func TestRun_LogSinks(t *testing.T) {
+	var terminal, file bytes.Buffer
+	cfg := snek.NewConfig(
+		snek.WithLogOutput(&terminal),
+		snek.WithLogSink(snek.LogSink{Output: &file, Format: "json", Level: "debug"}),
+	)
+	require.NoError(t, runLogSinkTest(t, cfg, []string{"--log-level", "warn"}), "Run should not return an error")
+
+	assert.NotContains(t, terminal.String(), "info test", "The log output should be filtered by the log level flag")
+	assert.Regexp(t, `WRN.* .*warn test`, terminal.String(), "The log output should use the formatted format")
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(file.String()), "\n") {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), "The sink should use the json format")
+		messages = append(messages, entry["message"].(string))
+	}
+	assert.Equal(t, []string{"Debug logging enabled.", "Logging initialized.", "debug test", "info test", "warn test"}, messages,
+		"The sink should be filtered by its own level")
+}
+
+func TestRun_LogSinks_Defaults(t *testing.T) {
+	var terminal, sink bytes.Buffer
+	cfg := snek.NewConfig(
+		snek.WithLogOutput(&terminal),
+		snek.WithDefaultLogFormat("json"),
+		snek.WithLogSink(snek.LogSink{Output: &sink}),
+	)
+	require.NoError(t, runLogSinkTest(t, cfg, []string{"--log-level", "debug"}), "Run should not return an error")
+	assert.Equal(t, terminal.String(), sink.String(), "A sink without a format or level should match the log output")
+	assert.Contains(t, sink.String(), `"message":"Debug logging enabled."`, "The sink should use the debug level")
+}
+
+func TestRun_LogSinks_LogFile(t *testing.T) {
+	var terminal, sink bytes.Buffer
+	path := t.TempDir() + "/app.log"
+	cfg := snek.NewConfig(
+		snek.WithLogOutput(&terminal),
+		snek.WithLogSink(snek.LogSink{Output: &sink, Level: "warn"}),
+		snek.WithLogFileFlag(true),
+	)
+	require.NoError(t, runLogSinkTest(t, cfg, []string{"--log-file", path}), "Run should not return an error")
+	assert.Empty(t, terminal.String(), "The log file should replace the log output")
+	assert.Contains(t, sink.String(), "warn test", "The sink should still be written to")
+	assert.NotContains(t, sink.String(), "info test", "The sink should be filtered by its own level")
+}
+
+func TestLogger_WithoutRun(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithUse("app"))
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Same(t, &log.Logger, snek.Logger(cmd).Logger, "Logger should return the global logger without Run")
+}
+
+func TestRun_Logger_Context(t *testing.T) {
+	var logs bytes.Buffer
+	sub, err := snek.NewCommand(
+		snek.WithUse("sub"),
+		snek.WithRunE(func(cmd *cobra.Command, _ []string) error {
+			assert.Equal(t, snek.Logger(cmd).Logger, zerolog.Ctx(cmd.Context()),
+				"The logger should be attached to the context of the command")
+			zerolog.Ctx(cmd.Context()).Info().Msg("from context")
+			return nil
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	err = snek.Run([]string{"sub"}, snek.NewConfig(snek.WithLogOutput(&logs), snek.WithDefaultLogFormat("json")),
+		snek.WithUse("app"),
+		snek.WithSubCommand(sub),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+	assert.Contains(t, logs.String(), `"message":"from context"`, "The context logger should write to the log output")
+}
+
+func TestRun_Logger_Global(t *testing.T) {
+	previous, previousLevel := log.Logger, zerolog.GlobalLevel()
+	t.Cleanup(func() {
+		log.Logger = previous
+		zerolog.SetGlobalLevel(previousLevel)
+	})
+
+	var global bytes.Buffer
+	log.Logger = zerolog.New(&global)
+
+	var logs bytes.Buffer
+	err := runLogSinkTest(t, snek.NewConfig(snek.WithLogOutput(&logs)), []string{"--log-level", "error"})
+	require.NoError(t, err, "Run should not return an error")
+	log.Info().Msg("global test")
+	assert.Contains(t, global.String(), "global test", "Run should not replace the global logger")
+	assert.Equal(t, previousLevel, zerolog.GlobalLevel(), "Run should not change the global level")
+	assert.NotContains(t, logs.String(), "global test", "The global logger should not write to the log output")
+
+	err = runLogSinkTest(t, snek.NewConfig(snek.WithLogOutput(&logs), snek.WithGlobalLogger(true)),
+		[]string{"--log-level", "warn"})
+	require.NoError(t, err, "Run should not return an error")
+	log.Warn().Msg("compatible test")
+	assert.Contains(t, logs.String(), "compatible test", "Run should replace the global logger with WithGlobalLogger")
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel(), "Run should set the global level with WithGlobalLogger")
+}
+
+func TestWithGlobalLogger(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.GlobalLogger, "GlobalLogger should be false")
+	cfg = snek.NewConfig(snek.WithGlobalLogger(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.GlobalLogger, "GlobalLogger should be true")
+}
+
+func TestRun_Logger_Concurrent(t *testing.T) {
+	levels := []string{"debug", "info", "warn", "error"}
+	outputs := make([]bytes.Buffer, len(levels))
+
+	var wg sync.WaitGroup
+	for i, level := range levels {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				err := snek.Run([]string{"--log-level", level}, snek.NewConfig(
+					snek.WithLogOutput(&outputs[i]),
+					snek.WithDefaultLogFormat("json"),
+					snek.WithShutdownSignals(),
+				),
+					snek.WithUse("app"),
+					snek.WithRun(func(cmd *cobra.Command, _ []string) {
+						snek.Logger(cmd).WithLevel(zerolog.ErrorLevel).Str("run", level).Msg("test")
+						snek.Logger(cmd).Debug().Str("run", level).Msg("debug test")
+					}),
+				)
+				assert.NoError(t, err, "Run should not return an error")
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, level := range levels {
+		output := outputs[i].String()
+		assert.Equal(t, 20, strings.Count(output, `"level":"error","run":"`+level+`"`),
+			"Each run should write its logs to its own output: %s", level)
+		for _, other := range levels {
+			if other != level {
+				assert.NotContains(t, output, `"run":"`+other+`"`, "Runs should not write to each other's output")
+			}
+		}
+		assert.Equal(t, level == "debug", strings.Contains(output, "debug test"),
+			"Each run should use its own level: %s", level)
+	}
+}
+
+func runLogComponentTest(t *testing.T, cfg *snek.Config, args []string) error {
+	t.Helper()
+	return snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) {
+			logger := snek.Logger(cmd)
+			logger.Debug().Msg("app debug")
+			logger.Info().Msg("app info")
+			logger.Component("db").Debug().Msg("db debug")
+			logger.Component("db").Info().Msg("db info")
+			logger.Component("http").Info().Msg("http info")
+			logger.Component("http").Warn().Msg("http warn")
+			logger.Component("cache").Debug().Msg("cache debug")
+			logger.Component("cache").Info().Msg("cache info")
+		}),
+	)
+}
+
+func TestRun_LogComponents(t *testing.T) {
+	tests := []struct {
+		name     string
+		levels   string
+		expected []string
+	}{
+		{
+			name:     "level",
+			levels:   "info",
+			expected: []string{"app info", "db info", "http info", "http warn", "cache info"},
+		},
+		{
+			name:     "components",
+			levels:   "info,db=debug,http=warn",
+			expected: []string{"app info", "db debug", "db info", "http warn", "cache info"},
+		},
+		{
+			name:     "spaces",
+			levels:   "warn, db = debug",
+			expected: []string{"db debug", "db info", "http warn"},
+		},
+		{
+			name:     "components without level",
+			levels:   "http=warn",
+			expected: []string{"app info", "db info", "http warn", "cache info"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			err := runLogComponentTest(t, snek.NewConfig(snek.WithLogOutput(&logs), snek.WithDefaultLogFormat("json")),
+				[]string{"--log-level", test.levels})
+			require.NoError(t, err, "Run should not return an error")
+
+			var messages []string
+			for _, line := range decodeLogLines(t, &logs) {
+				if message := line["message"].(string); message != "Logging initialized." && message != "Debug logging enabled." {
+					messages = append(messages, message)
+				}
+			}
+			assert.Equal(t, test.expected, messages, "The components should log at their levels")
+		})
+	}
+}
+
+func TestRun_LogComponents_Fields(t *testing.T) {
+	var logs bytes.Buffer
+	err := runLogComponentTest(t, snek.NewConfig(snek.WithLogOutput(&logs), snek.WithDefaultLogFormat("json")),
+		[]string{"--log-level", "info,db=debug"})
+	require.NoError(t, err, "Run should not return an error")
+
+	components := map[string]any{}
+	for _, line := range decodeLogLines(t, &logs) {
+		switch line["message"] {
+		case "Logging initialized.":
+			assert.Equal(t, "info", line["level"], "The log output level should be logged")
+			assert.Equal(t, map[string]any{"db": "debug"}, line["components"], "The component levels should be logged")
+		case "app info":
+			assert.NotContains(t, line, "component", "The logger should not add a component field")
+		default:
+			components[line["message"].(string)] = line["component"]
+		}
+	}
+	assert.Equal(t, map[string]any{
+		"db debug":   "db",
+		"db info":    "db",
+		"http info":  "http",
+		"http warn":  "http",
+		"cache info": "cache",
+	}, components, "The component loggers should add a component field")
+}
+
+func TestRun_LogComponents_Sinks(t *testing.T) {
+	var logs, inherited, own bytes.Buffer
+	err := runLogComponentTest(t, snek.NewConfig(
+		snek.WithLogOutput(&logs),
+		snek.WithLogSink(snek.LogSink{Output: &inherited, Format: "json"}),
+		snek.WithLogSink(snek.LogSink{Output: &own, Format: "json", Level: "info"}),
+	), []string{"--log-level", "warn,db=debug"})
+	require.NoError(t, err, "Run should not return an error")
+
+	assert.Contains(t, logs.String(), "db debug", "The log output should use the component level")
+	assert.NotContains(t, logs.String(), "app info", "The log output should use its level")
+	assert.Contains(t, inherited.String(), "db debug", "A sink without a level should use the component level")
+	assert.NotContains(t, inherited.String(), "app info", "A sink without a level should use the log output level")
+	assert.NotContains(t, own.String(), "db debug", "A sink with a level should not use the component level")
+	assert.Contains(t, own.String(), "db info", "A sink with a level should use its level")
+	assert.Contains(t, own.String(), "app info", "A sink with a level should use its level")
+}
+
+func TestRun_LogComponents_Global(t *testing.T) {
+	previous, previousLevel := log.Logger, zerolog.GlobalLevel()
+	t.Cleanup(func() {
+		log.Logger = previous
+		zerolog.SetGlobalLevel(previousLevel)
+	})
+
+	var logs bytes.Buffer
+	err := runLogComponentTest(t, snek.NewConfig(snek.WithLogOutput(&logs), snek.WithGlobalLogger(true)),
+		[]string{"--log-level", "warn,db=debug"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel(), "The global level should allow the component levels")
+	assert.Contains(t, logs.String(), "db debug", "The component should log at its level")
+}
+
+func TestRun_LogComponents_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		levels   string
+		expected string
+	}{
+		{name: "level", levels: "loud,db=debug", expected: `"loud"`},
+		{name: "component level", levels: "info,db=loud", expected: `"loud" for component "db"`},
+		{name: "empty component level", levels: "info,db=", expected: `"" for component "db"`},
+		{name: "empty component name", levels: "info,=debug", expected: "component name is empty"},
+		{name: "repeated level", levels: "info,warn", expected: "level is specified more than once"},
+		{name: "repeated component", levels: "db=info,db=warn", expected: `component "db" is specified more than once`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := runLogComponentTest(t, snek.NewConfig(snek.WithLogOutput(io.Discard), snek.WithDefaultLogLevel(test.levels)), nil)
+			require.ErrorIs(t, err, snek.ErrLogLevelInvalid, "Run should validate the default log level")
+			assert.ErrorContains(t, err, test.expected, "The error should describe the invalid level")
+
+			err = runLogComponentTest(t, snek.NewConfig(snek.WithLogOutput(io.Discard)), []string{"--log-level", test.levels})
+			require.ErrorIs(t, err, snek.ErrLogLevelInvalid, "Run should validate the log level flag")
+			assert.ErrorContains(t, err, test.expected, "The error should describe the invalid level")
+		})
+	}
+}
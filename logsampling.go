@@ -0,0 +1,161 @@
+package snek
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// logSamplingLevels are the levels logs can be sampled at.
+var logSamplingLevels = []string{"debug", "error", "info", "trace", "warn"}
+
+// LogSampling limits how many logs of a level are written, so verbose levels
+// such as debug remain usable for commands that log millions of lines.
+//
+// When Burst and Period are set, the first Burst logs of every Period are
+// written. Once the burst is exhausted, every Nth log is written when Every is
+// set, and no logs are written otherwise. When only Every is set, every Nth
+// log is written.
+type LogSampling struct {
+	// Burst is the number of logs written in each period.
+	Burst uint32
+
+	// Period is the period the burst is allowed in.
+	Period time.Duration
+
+	// Every is N when every Nth log is written.
+	Every uint32
+}
+
+// validate returns an ErrLogSamplingInvalid error if the sampling does not
+// write any logs, or has a burst without a period or a period without a burst.
+func (s LogSampling) validate() error {
+	switch {
+	case s.Period < 0:
+		return fmt.Errorf("%w: period is negative", ErrLogSamplingInvalid)
+	case s.Burst > 0 && s.Period == 0:
+		return fmt.Errorf("%w: burst has no period", ErrLogSamplingInvalid)
+	case s.Burst == 0 && s.Period > 0:
+		return fmt.Errorf("%w: period has no burst", ErrLogSamplingInvalid)
+	case s.Burst == 0 && s.Every == 0:
+		return fmt.Errorf("%w: no burst or every", ErrLogSamplingInvalid)
+	}
+	return nil
+}
+
+// sampler returns the zerolog sampler of the sampling.
+func (s LogSampling) sampler() zerolog.Sampler {
+	var every zerolog.Sampler
+	if s.Every > 0 {
+		every = &zerolog.BasicSampler{N: s.Every}
+	}
+	if s.Burst == 0 {
+		return every
+	}
+	return &zerolog.BurstSampler{Burst: s.Burst, Period: s.Period, NextSampler: every}
+}
+
+// validateLogSampling returns an ErrLogSamplingInvalid error if a level of the
+// sampling cannot be sampled, or the sampling of a level is invalid.
+func validateLogSampling(sampling map[string]LogSampling) error {
+	for _, level := range slices.Sorted(maps.Keys(sampling)) {
+		if !slices.Contains(logSamplingLevels, level) {
+			log.Error().Str("level", level).Strs("levels", logSamplingLevels).Msg("Log sampling level is invalid")
+			return fmt.Errorf("%w: level %q cannot be sampled", ErrLogSamplingInvalid, level)
+		}
+
+		if err := sampling[level].validate(); err != nil {
+			log.Error().Err(err).Str("level", level).Msg("Log sampling is invalid")
+			return fmt.Errorf("%w for level %q", err, level)
+		}
+	}
+	return nil
+}
+
+// newLogSampler returns a sampler that samples the logs of each level of the
+// sampling, or nil when nothing is sampled.
+func newLogSampler(sampling map[string]LogSampling) zerolog.Sampler {
+	if len(sampling) == 0 {
+		return nil
+	}
+
+	sampler := &zerolog.LevelSampler{}
+	for level, s := range sampling {
+		switch level {
+		case "trace":
+			sampler.TraceSampler = s.sampler()
+		case "debug":
+			sampler.DebugSampler = s.sampler()
+		case "info":
+			sampler.InfoSampler = s.sampler()
+		case "warn":
+			sampler.WarnSampler = s.sampler()
+		case "error":
+			sampler.ErrorSampler = s.sampler()
+		}
+	}
+	return sampler
+}
+
+// parseLogSampling parses a log sampling specification, which is a comma
+// separated list of `level=sampling` pairs. The sampling is either `N` to write
+// every Nth log, `burst/period` to write a burst of logs in each period, or
+// `burst/period:N` to write every Nth log once the burst is exhausted, such as
+// `debug=100/1s:10,trace=1000`. If the specification cannot be parsed, then an
+// ErrLogSamplingInvalid error is returned.
+func parseLogSampling(spec string) (map[string]LogSampling, error) {
+	sampling := map[string]LogSampling{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		level, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not a level=sampling pair", ErrLogSamplingInvalid, part)
+		}
+
+		level, value = strings.TrimSpace(level), strings.TrimSpace(value)
+		if _, ok := sampling[level]; ok {
+			return nil, fmt.Errorf("%w: level %q is specified more than once", ErrLogSamplingInvalid, level)
+		}
+
+		var s LogSampling
+		var err error
+		every := value
+		if burst, rest, ok := strings.Cut(value, "/"); ok {
+			var period string
+			period, every, _ = strings.Cut(rest, ":")
+			if s.Burst, err = parseLogSamplingCount(burst); err != nil {
+				return nil, fmt.Errorf("%w: burst %q for level %q", ErrLogSamplingInvalid, burst, level)
+			}
+			if s.Period, err = time.ParseDuration(period); err != nil {
+				return nil, fmt.Errorf("%w: period %q for level %q", ErrLogSamplingInvalid, period, level)
+			}
+		}
+		if every != "" {
+			if s.Every, err = parseLogSamplingCount(every); err != nil {
+				return nil, fmt.Errorf("%w: every %q for level %q", ErrLogSamplingInvalid, every, level)
+			}
+		}
+		sampling[level] = s
+	}
+
+	if err := validateLogSampling(sampling); err != nil {
+		return nil, err
+	}
+	return sampling, nil
+}
+
+// parseLogSamplingCount parses a count of a log sampling.
+func parseLogSamplingCount(count string) (uint32, error) {
+	n, err := strconv.ParseUint(count, 10, 32)
+	return uint32(n), err
+}
@@ -0,0 +1,242 @@
+package snek_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+// runLogSamplingTest runs a command that writes 100 trace logs and 100 info
+// logs, and returns the number of each written to the log output.
+func runLogSamplingTest(t *testing.T, cfg *snek.Config, args []string) (int, int, error) {
+	t.Helper()
+	var logs bytes.Buffer
+	snek.WithLogOutput(&logs)(cfg)
+	snek.WithDefaultLogFormat("json")(cfg)
+	snek.WithDefaultLogLevel("trace")(cfg)
+	err := snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) {
+			for range 100 {
+				snek.Logger(cmd).Trace().Msg("trace test")
+				snek.Logger(cmd).Info().Msg("info test")
+			}
+		}),
+	)
+	return strings.Count(logs.String(), "trace test"), strings.Count(logs.String(), "info test"), err
+}
+
+func TestWithLogSampling(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Empty(t, cfg.LogSampling, "The default log sampling should be empty")
+
+	debug := snek.LogSampling{Burst: 10, Period: time.Second, Every: 5}
+	trace := snek.LogSampling{Every: 100}
+	cfg = snek.NewConfig(snek.WithLogSampling("debug", debug), snek.WithLogSampling("trace", trace))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, map[string]snek.LogSampling{"debug": debug, "trace": trace}, cfg.LogSampling,
+		"WithLogSampling should set the sampling of the levels")
+}
+
+func TestWithLogSamplingFlag(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.LogSamplingFlag, "LogSamplingFlag should be false")
+	cfg = snek.NewConfig(snek.WithLogSamplingFlag(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.LogSamplingFlag, "LogSamplingFlag should be true")
+}
+
+func TestWithLogSamplingCommandLineVariableHelp(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.NotEmpty(t, cfg.LogSamplingCommandLineVariableHelp,
+		"The default log sampling command line variable help should be set")
+	cfg = snek.NewConfig(snek.WithLogSamplingCommandLineVariableHelp("How to sample logs."))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "How to sample logs.", cfg.LogSamplingCommandLineVariableHelp,
+		"LogSamplingCommandLineVariableHelp should be set")
+}
+
+func TestWithLogSamplingCommandLineVariableLongName(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "log-sampling", cfg.LogSamplingCommandLineVariableLongName,
+		"The default log sampling command line variable long name should be log-sampling")
+	cfg = snek.NewConfig(snek.WithLogSamplingCommandLineVariableLongName("sampling"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "sampling", cfg.LogSamplingCommandLineVariableLongName,
+		"LogSamplingCommandLineVariableLongName should be sampling")
+}
+
+func TestWithLogSamplingEnvironmentVariableName(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "LOG_SAMPLING", cfg.LogSamplingEnvironmentVariableName,
+		"The default log sampling environment variable name should be LOG_SAMPLING")
+	cfg = snek.NewConfig(snek.WithLogSamplingEnvironmentVariableName("SAMPLING"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "SAMPLING", cfg.LogSamplingEnvironmentVariableName,
+		"LogSamplingEnvironmentVariableName should be SAMPLING")
+}
+
+func TestRun_LogSampling(t *testing.T) {
+	allowAllLogLevels(t)
+
+	tests := []struct {
+		name     string
+		sampling snek.LogSampling
+		expected int
+	}{
+		{name: "every", sampling: snek.LogSampling{Every: 10}, expected: 10},
+		{name: "burst", sampling: snek.LogSampling{Burst: 5, Period: time.Hour}, expected: 5},
+		{name: "burst and every", sampling: snek.LogSampling{Burst: 5, Period: time.Hour, Every: 10}, expected: 15},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trace, info, err := runLogSamplingTest(t, snek.NewConfig(snek.WithLogSampling("trace", test.sampling)), nil)
+			require.NoError(t, err, "Run should not return an error")
+			assert.Equal(t, test.expected, trace, "The trace logs should be sampled")
+			assert.Equal(t, 100, info, "The info logs should not be sampled")
+		})
+	}
+}
+
+func TestRun_LogSamplingFlag(t *testing.T) {
+	allowAllLogLevels(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		trace    int
+		info     int
+		expected string
+	}{
+		{name: "config", trace: 50, info: 100},
+		{name: "every", args: []string{"--log-sampling", "trace=10"}, trace: 10, info: 100},
+		{name: "burst", args: []string{"--log-sampling", "trace=5/1h"}, trace: 5, info: 100},
+		{name: "burst and every", args: []string{"--log-sampling", "trace=5/1h:10"}, trace: 15, info: 100},
+		{name: "levels", args: []string{"--log-sampling", "trace=10, info=20"}, trace: 10, info: 5},
+		{name: "environment variable", env: "info=4", trace: 50, info: 25},
+		{name: "not a pair", args: []string{"--log-sampling", "trace"}, expected: `"trace" is not a level=sampling pair`},
+		{name: "invalid every", args: []string{"--log-sampling", "trace=x"}, expected: `every "x" for level "trace"`},
+		{name: "invalid burst", args: []string{"--log-sampling", "trace=x/1s"}, expected: `burst "x" for level "trace"`},
+		{name: "invalid period", args: []string{"--log-sampling", "trace=5/x"}, expected: `period "x" for level "trace"`},
+		{name: "no period", args: []string{"--log-sampling", "trace=5/0s"}, expected: `burst has no period for level "trace"`},
+		{name: "no logs", args: []string{"--log-sampling", "trace=0"}, expected: `no burst or every for level "trace"`},
+		{name: "repeated level", args: []string{"--log-sampling", "trace=5,trace=10"}, expected: `level "trace" is specified more than once`},
+		{name: "level", args: []string{"--log-sampling", "fatal=10"}, expected: `level "fatal" cannot be sampled`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("APP_LOG_SAMPLING", test.env)
+			}
+			cfg := snek.NewConfig(
+				snek.WithEnvironmentVariablePrefix("APP_"),
+				snek.WithLogSampling("trace", snek.LogSampling{Every: 2}),
+				snek.WithLogSamplingFlag(true),
+			)
+			trace, info, err := runLogSamplingTest(t, cfg, test.args)
+			if test.expected != "" {
+				require.ErrorIs(t, err, snek.ErrLogSamplingInvalid, "Run should validate the log sampling flag")
+				assert.ErrorContains(t, err, test.expected, "The error should describe the invalid sampling")
+				return
+			}
+			require.NoError(t, err, "Run should not return an error")
+			assert.Equal(t, test.trace, trace, "The trace logs should be sampled")
+			assert.Equal(t, test.info, info, "The info logs should be sampled")
+		})
+	}
+}
+
+func TestRun_LogSamplingFlag_Disabled(t *testing.T) {
+	err := snek.Run([]string{"--log-sampling", "trace=10"}, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithUse("app"),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	assert.ErrorContains(t, err, "unknown flag: --log-sampling", "Run should not add the log sampling flag by default")
+}
+
+func TestRun_LogSamplingFlag_EnvironmentVariableName(t *testing.T) {
+	allowAllLogLevels(t)
+	t.Setenv("APP_SAMPLING", "info=4")
+	cfg := snek.NewConfig(
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithLogSamplingFlag(true),
+		snek.WithLogSamplingEnvironmentVariableName("SAMPLING"),
+	)
+	_, info, err := runLogSamplingTest(t, cfg, nil)
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, 25, info, "The renamed environment variable should set the log sampling")
+
+	cfg = snek.NewConfig(snek.WithLogSamplingFlag(true), snek.WithLogSamplingEnvironmentVariableName(""))
+	_, _, err = runLogSamplingTest(t, cfg, nil)
+	assert.ErrorIs(t, err, snek.ErrLogSamplingEnvironmentVariableNameEmpty,
+		"Run should return ErrLogSamplingEnvironmentVariableNameEmpty for an empty environment variable name")
+}
+
+func TestRun_LogSamplingFlag_CommandLineVariableName(t *testing.T) {
+	allowAllLogLevels(t)
+	cfg := snek.NewConfig(
+		snek.WithLogSamplingFlag(true),
+		snek.WithLogSamplingCommandLineVariableLongName("sampling"),
+	)
+	_, info, err := runLogSamplingTest(t, cfg, []string{"--sampling", "info=4"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, 25, info, "The renamed flag should set the log sampling")
+
+	var help bytes.Buffer
+	cfg = snek.NewConfig(
+		snek.WithLogOutput(io.Discard),
+		snek.WithLogSamplingFlag(true),
+		snek.WithLogSamplingCommandLineVariableHelp("How to sample logs."),
+	)
+	err = snek.Run([]string{"--help"}, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+		func(cmd *cobra.Command) error {
+			cmd.SetOut(&help)
+			return nil
+		},
+	)
+	require.NoError(t, err, "Run should not return an error")
+	assert.Contains(t, help.String(), "How to sample logs.", "The help of the log sampling flag should be configurable")
+
+	cfg = snek.NewConfig(snek.WithLogSamplingFlag(true), snek.WithLogSamplingCommandLineVariableLongName(""))
+	_, _, err = runLogSamplingTest(t, cfg, nil)
+	assert.ErrorIs(t, err, snek.ErrLogSamplingCommandLineVariableNameEmpty,
+		"Run should return ErrLogSamplingCommandLineVariableNameEmpty for an empty command line variable name")
+}
+
+func TestRun_Config_InvalidLogSampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		sampling snek.LogSampling
+		expected string
+	}{
+		{name: "level", level: "fatal", sampling: snek.LogSampling{Every: 10}, expected: `level "fatal" cannot be sampled`},
+		{name: "negative period", level: "debug", sampling: snek.LogSampling{Burst: 5, Period: -time.Second}, expected: "period is negative"},
+		{name: "no period", level: "debug", sampling: snek.LogSampling{Burst: 5}, expected: "burst has no period"},
+		{name: "no burst", level: "debug", sampling: snek.LogSampling{Period: time.Second, Every: 5}, expected: "period has no burst"},
+		{name: "no logs", level: "debug", sampling: snek.LogSampling{}, expected: "no burst or every"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := runLogSamplingTest(t, snek.NewConfig(snek.WithLogSampling(test.level, test.sampling)), nil)
+			require.ErrorIs(t, err, snek.ErrLogSamplingInvalid, "Run should validate the log sampling")
+			assert.ErrorContains(t, err, test.expected, "The error should describe the invalid sampling")
+		})
+	}
+}
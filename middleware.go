@@ -0,0 +1,122 @@
+package snek
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// RunFunc is the function signature used by cobra for the RunE member of a
+// command.
+type RunFunc func(cmd *Command, args []string) error
+
+// Middleware is a function that wraps the run function of a command. The
+// returned RunFunc is called in place of next, and is responsible for calling
+// next if the command should continue executing.
+type Middleware func(next RunFunc) RunFunc
+
+// WithMiddleware adds the specified middleware to the command. Middleware is
+// applied once all initializers passed to NewCommand have been called, so it
+// may be specified before or after the run function. The first middleware
+// specified is the outermost, and is therefore called first.
+//
+// Commands using Run are converted to use RunE when middleware is applied.
+func WithMiddleware(middleware ...Middleware) Initializer {
+	return func(cmd *Command) error {
+		ext := extensionsFor(cmd)
+		ext.middleware = append(ext.middleware, middleware...)
+		return nil
+	}
+}
+
+// RecoverMiddleware returns a middleware that recovers from any panic raised
+// by the command and returns it as an error wrapping ErrCommandPanicked. The
+// panic value and stack trace are logged at the error level.
+func RecoverMiddleware() Middleware {
+	return func(next RunFunc) RunFunc {
+		return func(cmd *Command, args []string) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					Logger(cmd).Error().
+						Str("command", cmd.CommandPath()).
+						Interface("panic", recovered).
+						Bytes("stack", debug.Stack()).
+						Msg("Command panicked")
+					err = fmt.Errorf("%w: %v", ErrCommandPanicked, recovered)
+				}
+			}()
+
+			return next(cmd, args)
+		}
+	}
+}
+
+// TimeoutMiddleware returns a middleware that cancels the command context once
+// the specified timeout has elapsed. Commands must respect the context returned
+// by cmd.Context() for the timeout to have any effect.
+func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(next RunFunc) RunFunc {
+		return func(cmd *Command, args []string) error {
+			parent := cmd.Context()
+			if parent == nil {
+				parent = context.Background()
+			}
+
+			ctx, cancel := context.WithTimeout(parent, timeout)
+			defer cancel()
+
+			cmd.SetContext(ctx)
+			defer cmd.SetContext(parent)
+			return next(cmd, args)
+		}
+	}
+}
+
+// TimingMiddleware returns a middleware that logs how long the command took to
+// execute at the info level, along with the error returned by the command if
+// there was one.
+func TimingMiddleware() Middleware {
+	return func(next RunFunc) RunFunc {
+		return func(cmd *Command, args []string) error {
+			start := time.Now()
+			err := next(cmd, args)
+			Logger(cmd).Info().
+				Err(err).
+				Str("command", cmd.CommandPath()).
+				Dur("duration", time.Since(start)).
+				Msg("Command executed.")
+			return err
+		}
+	}
+}
+
+// applyMiddleware wraps the run function of the command with the specified
+// middleware, the first middleware being the outermost. If the command is not
+// runnable, or no middleware is specified, then the command is not changed.
+func applyMiddleware(cmd *Command, middleware []Middleware) {
+	if len(middleware) == 0 {
+		return
+	}
+
+	var run RunFunc
+	switch {
+	case cmd.RunE != nil:
+		run = cmd.RunE
+	case cmd.Run != nil:
+		simple := cmd.Run
+		run = func(cmd *Command, args []string) error {
+			simple(cmd, args)
+			return nil
+		}
+	default:
+		return
+	}
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		run = middleware[i](run)
+	}
+
+	cmd.Run = nil
+	cmd.RunE = run
+}
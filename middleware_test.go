@@ -0,0 +1,138 @@
+package snek_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func recordingMiddleware(calls *[]string, name string) snek.Middleware {
+	return func(next snek.RunFunc) snek.RunFunc {
+		return func(cmd *cobra.Command, args []string) error {
+			*calls = append(*calls, name)
+			return next(cmd, args)
+		}
+	}
+}
+
+func TestWithMiddleware_Order(t *testing.T) {
+	var calls []string
+	cmd, err := snek.NewCommand(
+		snek.WithMiddleware(
+			recordingMiddleware(&calls, "first"),
+			recordingMiddleware(&calls, "second"),
+		),
+		snek.WithRunE(func(*cobra.Command, []string) error {
+			calls = append(calls, "run")
+			return nil
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute(), "Execute should not return an error")
+	assert.Equal(t, []string{"first", "second", "run"}, calls,
+		"Middleware should be called in the order it was specified before the run function")
+}
+
+func TestWithMiddleware_Run(t *testing.T) {
+	var calls []string
+	cmd, err := snek.NewCommand(
+		snek.WithRun(func(*cobra.Command, []string) {
+			calls = append(calls, "run")
+		}),
+		snek.WithMiddleware(recordingMiddleware(&calls, "middleware")),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Nil(t, cmd.Run, "Run should be cleared when middleware is applied")
+	require.NotNil(t, cmd.RunE, "RunE should be set when middleware is applied")
+
+	require.NoError(t, cmd.RunE(cmd, nil), "RunE should not return an error")
+	assert.Equal(t, []string{"middleware", "run"}, calls,
+		"Middleware should wrap the Run function")
+}
+
+func TestWithMiddleware_NotRunnable(t *testing.T) {
+	var calls []string
+	cmd, err := snek.NewCommand(snek.WithMiddleware(recordingMiddleware(&calls, "middleware")))
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.False(t, cmd.Runnable(), "Middleware should not make a command runnable")
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	cmd, err := snek.NewCommand(
+		snek.WithMiddleware(snek.RecoverMiddleware()),
+		snek.WithRunE(func(*cobra.Command, []string) error {
+			panic("boom")
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	err = cmd.RunE(cmd, nil)
+	assert.ErrorIs(t, err, snek.ErrCommandPanicked, "The panic should be returned as an error")
+	assert.ErrorContains(t, err, "boom", "The error should contain the panic value")
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	cmd, err := snek.NewCommand(
+		snek.WithMiddleware(snek.TimeoutMiddleware(time.Millisecond)),
+		snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+			<-cmd.Context().Done()
+			return cmd.Context().Err()
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	err = cmd.ExecuteContext(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "The command context should time out")
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	var buffer bytes.Buffer
+	err := snek.Run(nil,
+		snek.NewConfig(
+			snek.WithDefaultLogFormat("json"),
+			snek.WithLogOutput(&buffer),
+			snek.WithGlobalMiddleware(snek.TimingMiddleware()),
+		),
+		snek.WithUse("root"),
+		snek.WithRun(func(*cobra.Command, []string) {}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	lines := parseLogLines(t, buffer.String())
+	require.Len(t, lines, 2, "Run should log the logging initialized and command executed messages")
+	assert.Equal(t, "Command executed.", lines[1].Message, "The timing middleware should log the execution")
+}
+
+func TestRun_GlobalMiddleware(t *testing.T) {
+	var calls []string
+	subCmd, err := snek.NewCommand(
+		snek.WithUse("sub"),
+		snek.WithMiddleware(recordingMiddleware(&calls, "local")),
+		snek.WithRun(func(*cobra.Command, []string) {
+			calls = append(calls, "sub")
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	err = snek.Run([]string{"sub"},
+		snek.NewConfig(
+			snek.WithLogOutput(io.Discard),
+			snek.WithGlobalMiddleware(recordingMiddleware(&calls, "global")),
+		),
+		snek.WithUse("root"),
+		snek.WithSubCommand(subCmd),
+	)
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, []string{"global", "local", "sub"}, calls,
+		"Global middleware should wrap the command middleware of subcommands")
+}
@@ -0,0 +1,153 @@
+package snek
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/ronelliott/snek/output"
+)
+
+// outputFormatValue is a flag value that only accepts output formats that can
+// be parsed by output.ParseFormat.
+type outputFormatValue struct {
+	format *output.Format
+}
+
+// Set sets the output format if it is valid.
+func (v *outputFormatValue) Set(value string) error {
+	format, err := output.ParseFormat(value)
+	if err != nil {
+		return err
+	}
+	*v.format = format
+	return nil
+}
+
+// String returns the output format.
+func (v *outputFormatValue) String() string {
+	return v.format.String()
+}
+
+// Type returns the type of the flag displayed in the help output.
+func (v *outputFormatValue) Type() string {
+	return "string"
+}
+
+// outputFlagUsage is the help text of the `--output` flag.
+const outputFlagUsage = "The output format. Valid values are json, jsonl, jsonpath=EXPRESSION, table, template=TEMPLATE, and yaml."
+
+// addOutputFlags adds the `--output`, `--columns` and `--no-headers` flags to
+// the persistent flags of the root command, storing their values in the output
+// options of the root command that are used by Print. The `-o` shorthand is
+// only added if the root command does not already use it.
+func addOutputFlags(root *Command, cfg *Config) error {
+	pflags := root.PersistentFlags()
+	for _, name := range []string{"output", "columns", "no-headers"} {
+		if root.Flags().Lookup(name) != nil || pflags.Lookup(name) != nil {
+			log.Error().Str("flag", name).Msg("Output flag is already defined")
+			return fmt.Errorf("%w: --%s", ErrFlagDuplicate, name)
+		}
+	}
+
+	shorthand := "o"
+	if root.Flags().ShorthandLookup(shorthand) != nil || pflags.ShorthandLookup(shorthand) != nil {
+		shorthand = ""
+	}
+
+	envVar := cfg.EnvironmentVariablePrefix + cfg.OutputEnvironmentVariableName
+	format, err := output.ParseFormat(cfg.DefaultOutputFormat)
+	if err != nil {
+		return err
+	}
+
+	if value, ok := os.LookupEnv(envVar); ok {
+		if format, err = output.ParseFormat(value); err != nil {
+			return fmt.Errorf("%w: %s=%q: %v", ErrFlagEnvVarInvalid, envVar, value, err)
+		}
+	}
+
+	ext := extensionsFor(root)
+	ext.output = &output.Options{Format: format}
+
+	pflags.VarP(&outputFormatValue{format: &ext.output.Format}, "output", shorthand, outputFlagUsage)
+	pflags.StringSliceVar(&ext.output.Columns, "columns", nil,
+		"The columns to include in the table output, in order.")
+	pflags.BoolVar(&ext.output.NoHeaders, "no-headers", false,
+		"Do not include the header row in the table output.")
+
+	if err := annotateEnvironmentVariable(pflags, "output", envVar); err != nil {
+		return err
+	}
+
+	if err := annotateBuiltin(pflags, "output", "columns", "no-headers"); err != nil {
+		return err
+	}
+
+	return root.RegisterFlagCompletionFunc("output", func(*Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			output.FormatJSON, output.FormatJSONL, output.FormatTable, output.FormatYAML,
+			output.FormatTemplate + "=", output.FormatJSONPath + "=",
+		}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	})
+}
+
+// Out returns the writer the data of the command, such as the results it
+// prints, should be written to. Diagnostics, such as logs, should never be
+// written to it, so the data can be piped to other programs.
+//
+// The writer is the output of the command, which is os.Stdout unless it is
+// changed with SetOut.
+func Out(cmd *Command) io.Writer {
+	return cmd.OutOrStdout()
+}
+
+// OutputOptions returns the output options used by Print for the command. If
+// Run did not add the output flags, then the options render values in the
+// default output format, which is a table.
+func OutputOptions(cmd *Command) output.Options {
+	if ext := lookupExtensions(cmd.Root()); ext != nil && ext.output != nil {
+		return *ext.output
+	}
+	return output.Options{Format: output.Format{Name: output.FormatTable}}
+}
+
+// Print renders the value to the data output of the command returned by Out in
+// the format selected with the `--output` flag. Structs and slices of structs
+// are rendered as tables by default, with the columns selected with the
+// `--columns` flag and the header row omitted with the `--no-headers` flag. See
+// the output package for how each format renders values.
+func Print(cmd *Command, value any) error {
+	return output.Print(Out(cmd), value, OutputOptions(cmd))
+}
+
+// logOutputFor returns the writer logs configured to be written to out are
+// written to. Logs configured to be written to os.Stdout, by the log output or
+// by a log sink, are written to os.Stderr instead when separate output is
+// enabled, or when the command prints its data in a format other than a table,
+// so the data written to os.Stdout is never interleaved with logs.
+func logOutputFor(cfg *Config, cmd *Command, out io.Writer) io.Writer {
+	if out != os.Stdout {
+		return out
+	}
+
+	if cfg.SeparateOutput || OutputOptions(cmd).Format.Name != output.FormatTable {
+		return os.Stderr
+	}
+
+	return out
+}
+
+// logSinksFor returns the log sinks of the config with their outputs
+// redirected by logOutputFor.
+func logSinksFor(cfg *Config, cmd *Command) []LogSink {
+	sinks := make([]LogSink, len(cfg.LogSinks))
+	for i, sink := range cfg.LogSinks {
+		sink.Output = logOutputFor(cfg, cmd, sink.Output)
+		sinks[i] = sink
+	}
+	return sinks
+}
@@ -0,0 +1,20 @@
+package output
+
+import "errors"
+
+var (
+	// ErrColumnNotFound is returned when a selected table column does not
+	// exist.
+	ErrColumnNotFound = errors.New("table column not found")
+
+	// ErrFormatInvalid is returned when the output format is invalid.
+	ErrFormatInvalid = errors.New("invalid output format")
+
+	// ErrJSONPathInvalid is returned when a JSONPath expression cannot be
+	// parsed.
+	ErrJSONPathInvalid = errors.New("invalid JSONPath expression")
+
+	// ErrJSONPathNotFound is returned when a JSONPath expression refers to a
+	// value that does not exist.
+	ErrJSONPathNotFound = errors.New("JSONPath value not found")
+)
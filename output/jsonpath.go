@@ -0,0 +1,187 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is a single step of a JSONPath expression, selecting either a
+// field of an object, an element of an array, or every element of an array.
+type jsonPathStep struct {
+	field    string
+	index    int
+	wildcard bool
+	isIndex  bool
+}
+
+// parseJSONPath parses a JSONPath expression such as `{.items[*].name}`. The
+// braces and the leading `$` are optional. Fields are selected with `.name` or
+// `['name']`, array elements with `[0]` or `[-1]`, and every element of an array
+// or object with `[*]` or `.*`.
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	path := strings.TrimSpace(expr)
+	if strings.HasPrefix(path, "{") {
+		if !strings.HasSuffix(path, "}") {
+			return nil, fmt.Errorf("%w: %q: unclosed brace", ErrJSONPathInvalid, expr)
+		}
+		path = strings.TrimSpace(path[1 : len(path)-1])
+	}
+	path = strings.TrimPrefix(path, "$")
+
+	var steps []jsonPathStep
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+
+			name := path[:end]
+			path = path[end:]
+			switch name {
+			case "":
+				if len(path) == 0 && len(steps) == 0 {
+					continue
+				}
+				return nil, fmt.Errorf("%w: %q: empty field name", ErrJSONPathInvalid, expr)
+			case "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			default:
+				steps = append(steps, jsonPathStep{field: name})
+			}
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: %q: unclosed bracket", ErrJSONPathInvalid, expr)
+			}
+
+			selector := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+			if selector == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+				continue
+			}
+
+			if unquoted, ok := strings.CutPrefix(selector, "'"); ok {
+				name, ok := strings.CutSuffix(unquoted, "'")
+				if !ok {
+					return nil, fmt.Errorf("%w: %q: unclosed quote", ErrJSONPathInvalid, expr)
+				}
+				steps = append(steps, jsonPathStep{field: name})
+				continue
+			}
+
+			index, err := strconv.Atoi(selector)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q: invalid index %q", ErrJSONPathInvalid, expr, selector)
+			}
+			steps = append(steps, jsonPathStep{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("%w: %q: unexpected %q", ErrJSONPathInvalid, expr, path[0])
+		}
+	}
+
+	return steps, nil
+}
+
+// evaluateJSONPath returns the values selected by the steps from the generic
+// JSON value.
+func evaluateJSONPath(expr string, value any, steps []jsonPathStep) ([]any, error) {
+	current := []any{value}
+	for _, step := range steps {
+		var next []any
+		for _, v := range current {
+			switch {
+			case step.wildcard:
+				switch v := v.(type) {
+				case []any:
+					next = append(next, v...)
+				case map[string]any:
+					for _, key := range sortedKeys(v) {
+						next = append(next, v[key])
+					}
+				default:
+					return nil, fmt.Errorf("%w: %q: cannot select every element of %T", ErrJSONPathNotFound, expr, v)
+				}
+			case step.isIndex:
+				array, ok := v.([]any)
+				if !ok {
+					return nil, fmt.Errorf("%w: %q: cannot index %T", ErrJSONPathNotFound, expr, v)
+				}
+
+				index := step.index
+				if index < 0 {
+					index += len(array)
+				}
+				if index < 0 || index >= len(array) {
+					return nil, fmt.Errorf("%w: %q: index %d out of range", ErrJSONPathNotFound, expr, step.index)
+				}
+				next = append(next, array[index])
+			default:
+				object, ok := v.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("%w: %q: cannot select field %q of %T", ErrJSONPathNotFound, expr, step.field, v)
+				}
+
+				field, ok := object[step.field]
+				if !ok {
+					return nil, fmt.Errorf("%w: %q: field %q not found", ErrJSONPathNotFound, expr, step.field)
+				}
+				next = append(next, field)
+			}
+		}
+		current = next
+	}
+
+	return current, nil
+}
+
+// printJSONPath renders each value selected by the JSONPath expression on its
+// own line. Strings are rendered as is, and other values as compact JSON.
+func printJSONPath(w io.Writer, value any, expr string) error {
+	steps, err := parseJSONPath(expr)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := toJSONValue(value)
+	if err != nil {
+		return err
+	}
+
+	results, err := evaluateJSONPath(expr, decoded, steps)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if s, ok := result.(string); ok {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of the object sorted by name.
+func sortedKeys(object map[string]any) []string {
+	return slices.Sorted(maps.Keys(object))
+}
@@ -0,0 +1,182 @@
+// Package output renders values as tables, JSON, YAML, JSON lines, Go
+// templates or JSONPath expressions. It is used by snek.Print to render the
+// data of a command in the format selected with the `--output` flag, but it
+// does not depend on snek and can be used on its own.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+const (
+	// FormatJSON renders the value as indented JSON.
+	FormatJSON = "json"
+
+	// FormatJSONL renders each element of a slice as compact JSON on its own
+	// line, or the value itself when it is not a slice.
+	FormatJSONL = "jsonl"
+
+	// FormatJSONPath renders the values selected by a JSONPath expression, such
+	// as `jsonpath={.items[*].name}`.
+	FormatJSONPath = "jsonpath"
+
+	// FormatTable renders the value as a table with a column for each field of
+	// a struct and a row for each element of a slice.
+	FormatTable = "table"
+
+	// FormatTemplate renders the value with a Go template, such as
+	// `template={{.Name}}`.
+	FormatTemplate = "template"
+
+	// FormatYAML renders the value as YAML.
+	FormatYAML = "yaml"
+)
+
+// Formats are the names of the supported output formats.
+var Formats = []string{FormatJSON, FormatJSONL, FormatJSONPath, FormatTable, FormatTemplate, FormatYAML}
+
+// Format is an output format, along with its argument for the formats that
+// require one.
+type Format struct {
+	// Name is the name of the format, such as `json` or `template`.
+	Name string
+
+	// Argument is the argument of the format, such as the template of the
+	// `template` format or the expression of the `jsonpath` format.
+	Argument string
+}
+
+// ParseFormat parses an output format in the form `name` or `name=argument`.
+// The `template` and `jsonpath` formats require an argument, and the other
+// formats do not accept one.
+//
+// If the format is invalid, then an error wrapping ErrFormatInvalid is
+// returned.
+func ParseFormat(value string) (Format, error) {
+	name, argument, hasArgument := strings.Cut(value, "=")
+	format := Format{Name: name, Argument: argument}
+	switch name {
+	case FormatJSON, FormatJSONL, FormatTable, FormatYAML:
+		if hasArgument {
+			return Format{}, fmt.Errorf("%w: %s does not accept an argument", ErrFormatInvalid, name)
+		}
+	case FormatTemplate:
+		if argument == "" {
+			return Format{}, fmt.Errorf("%w: %s requires a template, i.e. %s={{.Name}}", ErrFormatInvalid, name, name)
+		}
+		if _, err := template.New("output").Parse(argument); err != nil {
+			return Format{}, fmt.Errorf("%w: %v", ErrFormatInvalid, err)
+		}
+	case FormatJSONPath:
+		if argument == "" {
+			return Format{}, fmt.Errorf("%w: %s requires an expression, i.e. %s={.name}", ErrFormatInvalid, name, name)
+		}
+		if _, err := parseJSONPath(argument); err != nil {
+			return Format{}, fmt.Errorf("%w: %v", ErrFormatInvalid, err)
+		}
+	default:
+		return Format{}, fmt.Errorf("%w: %q, valid formats are %s", ErrFormatInvalid, value, strings.Join(Formats, ", "))
+	}
+
+	return format, nil
+}
+
+// String returns the format in the form accepted by ParseFormat.
+func (f Format) String() string {
+	if f.Argument == "" {
+		return f.Name
+	}
+	return f.Name + "=" + f.Argument
+}
+
+// Options are the options used to render a value.
+type Options struct {
+	// Format is the format the value is rendered in. If the name of the format
+	// is empty, then the value is rendered as a table.
+	Format Format
+
+	// Columns are the names of the columns rendered by the table format, in
+	// order. If no columns are selected, then every column is rendered.
+	Columns []string
+
+	// NoHeaders is true when the table format should not render the header
+	// row.
+	NoHeaders bool
+}
+
+// Print renders the value to the writer with the specified options.
+func Print(w io.Writer, value any, opts Options) error {
+	switch opts.Format.Name {
+	case FormatTable, "":
+		return printTable(w, value, opts.Columns, opts.NoHeaders)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case FormatJSONL:
+		return printJSONL(w, value)
+	case FormatYAML:
+		return printYAML(w, value)
+	case FormatTemplate:
+		tmpl, err := template.New("output").Parse(opts.Format.Argument)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrFormatInvalid, err)
+		}
+		return tmpl.Execute(w, value)
+	case FormatJSONPath:
+		return printJSONPath(w, value, opts.Format.Argument)
+	default:
+		return fmt.Errorf("%w: %q", ErrFormatInvalid, opts.Format.Name)
+	}
+}
+
+// printJSONL renders each element of a slice as compact JSON on its own line,
+// or the value itself when it is not a slice.
+func printJSONL(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	rv := indirect(reflect.ValueOf(value))
+	if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) {
+		return encoder.Encode(value)
+	}
+
+	for i := range rv.Len() {
+		if err := encoder.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toJSONValue converts the value to the generic value it is encoded as in
+// JSON, so it can be rendered using the JSON names of its fields.
+func toJSONValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// indirect dereferences the value until it is not a pointer or interface.
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
@@ -0,0 +1,177 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek/output"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type user struct {
+	Name    string    `json:"name"`
+	Age     int       `json:"age"`
+	Email   string    `json:"email,omitempty" output:"-"`
+	Tags    []string  `json:"tags"`
+	Joined  time.Time `json:"joined"`
+	Address *address  `json:"address"`
+	secret  string
+}
+
+var (
+	joined = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	users  = []user{
+		{Name: "alice", Age: 30, Tags: []string{"admin", "dev"}, Joined: joined, Address: &address{City: "Paris"}},
+		{Name: "bob", Age: 4, Joined: joined, secret: "hidden"},
+	}
+)
+
+func print(t *testing.T, value any, opts output.Options) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, output.Print(&buf, value, opts), "Print should not return an error")
+	return buf.String()
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected output.Format
+		err      bool
+	}{
+		{value: "json", expected: output.Format{Name: output.FormatJSON}},
+		{value: "jsonl", expected: output.Format{Name: output.FormatJSONL}},
+		{value: "table", expected: output.Format{Name: output.FormatTable}},
+		{value: "yaml", expected: output.Format{Name: output.FormatYAML}},
+		{value: "template={{.Name}}", expected: output.Format{Name: output.FormatTemplate, Argument: "{{.Name}}"}},
+		{value: "jsonpath={.items[*].name}", expected: output.Format{Name: output.FormatJSONPath, Argument: "{.items[*].name}"}},
+		{value: "xml", err: true},
+		{value: "json=x", err: true},
+		{value: "template", err: true},
+		{value: "template={{.Name", err: true},
+		{value: "jsonpath=", err: true},
+		{value: "jsonpath={.items[}", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			format, err := output.ParseFormat(tt.value)
+			if tt.err {
+				assert.ErrorIs(t, err, output.ErrFormatInvalid, "ParseFormat should return ErrFormatInvalid")
+				return
+			}
+
+			require.NoError(t, err, "ParseFormat should not return an error")
+			assert.Equal(t, tt.expected, format, "ParseFormat should parse the format")
+			assert.Equal(t, tt.value, format.String(), "String should return the parsed value")
+		})
+	}
+}
+
+func TestPrint_Table(t *testing.T) {
+	expected := "" +
+		"NAME    AGE   TAGS        JOINED                 ADDRESS\n" +
+		"alice   30    admin,dev   2024-01-02T03:04:05Z   {\"city\":\"Paris\"}\n" +
+		"bob     4                 2024-01-02T03:04:05Z   \n"
+	assert.Equal(t, expected, print(t, users, output.Options{}), "Print should render a slice as a table")
+
+	assert.Equal(t, "NAME    AGE\nalice   30\n", print(t, &users[0], output.Options{
+		Format:  output.Format{Name: output.FormatTable},
+		Columns: []string{"name", "AGE"},
+	}), "Print should render a struct as a single row with the selected columns")
+
+	assert.Equal(t, "30   alice\n4    bob\n", print(t, users, output.Options{
+		Columns:   []string{"age", "name"},
+		NoHeaders: true,
+	}), "Print should render the selected columns in order without headers")
+}
+
+func TestPrint_Table_Maps(t *testing.T) {
+	rows := []map[string]any{{"b": 1, "a": "x"}, {"a": "y", "c": true}}
+	assert.Equal(t, "A   B   C\nx   1   \ny       true\n", print(t, rows, output.Options{}),
+		"Print should render a column for each key of the maps")
+}
+
+func TestPrint_Table_Scalars(t *testing.T) {
+	assert.Equal(t, "VALUE\na\nb\n", print(t, []string{"a", "b"}, output.Options{}),
+		"Print should render a single column for scalars")
+}
+
+func TestPrint_Table_ColumnNotFound(t *testing.T) {
+	err := output.Print(&bytes.Buffer{}, users, output.Options{Columns: []string{"missing"}})
+	assert.ErrorIs(t, err, output.ErrColumnNotFound, "Print should return ErrColumnNotFound")
+}
+
+func TestPrint_JSON(t *testing.T) {
+	expected := "{\n  \"city\": \"Paris\"\n}\n"
+	assert.Equal(t, expected, print(t, address{City: "Paris"}, output.Options{Format: output.Format{Name: output.FormatJSON}}),
+		"Print should render indented JSON")
+}
+
+func TestPrint_JSONL(t *testing.T) {
+	opts := output.Options{Format: output.Format{Name: output.FormatJSONL}}
+	assert.Equal(t, "{\"city\":\"Paris\"}\n{\"city\":\"Lyon\"}\n",
+		print(t, []address{{City: "Paris"}, {City: "Lyon"}}, opts),
+		"Print should render each element of a slice on its own line")
+	assert.Equal(t, "{\"city\":\"Paris\"}\n", print(t, address{City: "Paris"}, opts),
+		"Print should render a single value on one line")
+}
+
+func TestPrint_YAML(t *testing.T) {
+	expected := "" +
+		"- name: alice\n" +
+		"  age: 30\n" +
+		"  tags:\n" +
+		"    - admin\n" +
+		"    - dev\n" +
+		"  joined: \"2024-01-02T03:04:05Z\"\n" +
+		"  address:\n" +
+		"    city: Paris\n"
+	assert.Equal(t, expected, print(t, users[:1], output.Options{Format: output.Format{Name: output.FormatYAML}}),
+		"Print should render YAML using the JSON field names in order")
+}
+
+func TestPrint_Template(t *testing.T) {
+	opts := output.Options{Format: output.Format{Name: output.FormatTemplate, Argument: "{{range .}}{{.Name}}={{.Age}}\n{{end}}"}}
+	assert.Equal(t, "alice=30\nbob=4\n", print(t, users, opts), "Print should render the template")
+}
+
+func TestPrint_JSONPath(t *testing.T) {
+	tests := []struct {
+		expr     string
+		value    any
+		expected string
+	}{
+		{expr: "{[*].name}", value: users, expected: "alice\nbob\n"},
+		{expr: "{$[0].age}", value: users, expected: "30\n"},
+		{expr: "[-1].name", value: users, expected: "bob\n"},
+		{expr: "{[0].address}", value: users, expected: "{\"city\":\"Paris\"}\n"},
+		{expr: "{[0]['tags'][1]}", value: users, expected: "dev\n"},
+		{expr: "{.}", value: address{City: "Paris"}, expected: "{\"city\":\"Paris\"}\n"},
+		{expr: "{.*}", value: map[string]int{"b": 2, "a": 1}, expected: "1\n2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			opts := output.Options{Format: output.Format{Name: output.FormatJSONPath, Argument: tt.expr}}
+			assert.Equal(t, tt.expected, print(t, tt.value, opts), "Print should render the selected values")
+		})
+	}
+}
+
+func TestPrint_JSONPath_NotFound(t *testing.T) {
+	for _, expr := range []string{"{[0].missing}", "{[5]}", "{.name}", "{[0].name[*]}"} {
+		t.Run(expr, func(t *testing.T) {
+			opts := output.Options{Format: output.Format{Name: output.FormatJSONPath, Argument: expr}}
+			err := output.Print(&bytes.Buffer{}, users, opts)
+			assert.ErrorIs(t, err, output.ErrJSONPathNotFound, "Print should return ErrJSONPathNotFound")
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// valueColumn is the name of the column of a table of values that are not
+// structs or maps.
+const valueColumn = "value"
+
+// column is a column of a table, along with the function extracting its cell
+// from a row.
+type column struct {
+	name string
+	cell func(row reflect.Value) reflect.Value
+}
+
+// printTable renders the value as a table. Each element of a slice or array
+// is rendered as a row, and any other value is rendered as a single row. The
+// columns of a struct are its exported fields, named by their `output` tag, or
+// their `json` tag if they do not have one, or their field name otherwise.
+// Fields tagged with `output:"-"` are not rendered. The columns of a map are
+// its keys, sorted by name.
+func printTable(w io.Writer, value any, selected []string, noHeaders bool) error {
+	var rows []reflect.Value
+	rv := indirect(reflect.ValueOf(value))
+	switch {
+	case !rv.IsValid():
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		for i := range rv.Len() {
+			rows = append(rows, indirect(rv.Index(i)))
+		}
+	default:
+		rows = append(rows, rv)
+	}
+
+	columns := tableColumns(value, rows)
+	if len(selected) > 0 {
+		var err error
+		if columns, err = selectColumns(columns, selected); err != nil {
+			return err
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	if !noHeaders {
+		headers := make([]string, len(columns))
+		for i, col := range columns {
+			headers[i] = strings.ToUpper(col.name)
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	}
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i] = formatCell(col.cell(row))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// tableColumns returns the columns of a table of the rows, determined by the
+// element type of the value, or by the keys of the rows if they are maps.
+func tableColumns(value any, rows []reflect.Value) []column {
+	typ := reflect.TypeOf(value)
+	for typ != nil && (typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ != nil && typ.Kind() == reflect.Struct && typ != reflect.TypeFor[time.Time]():
+		return structColumns(typ, nil)
+	case typ != nil && typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		return mapColumns(rows)
+	default:
+		return []column{{name: valueColumn, cell: func(row reflect.Value) reflect.Value { return row }}}
+	}
+}
+
+// structColumns returns a column for each exported field of the struct type,
+// including the fields of embedded structs.
+func structColumns(typ reflect.Type, index []int) []column {
+	var columns []column
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		fieldIndex := append(slices.Clone(index), i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := columnName(field)
+		if !ok {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct && name == "" {
+			columns = append(columns, structColumns(fieldType, fieldIndex)...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		columns = append(columns, column{
+			name: name,
+			cell: func(row reflect.Value) reflect.Value {
+				return fieldByIndex(row, fieldIndex)
+			},
+		})
+	}
+	return columns
+}
+
+// columnName returns the name of the column of the struct field from its
+// `output` or `json` tag, which is empty if the tag does not set a name. If the
+// field is excluded with a tag of `-`, then false is returned.
+func columnName(field reflect.StructField) (string, bool) {
+	for _, key := range []string{"output", "json"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				return "", false
+			}
+			if name != "" {
+				return name, true
+			}
+		}
+	}
+	return "", true
+}
+
+// fieldByIndex returns the nested field of the struct with the index, or the
+// zero value if an embedded struct pointer along the way is nil.
+func fieldByIndex(row reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		row = indirect(row)
+		if !row.IsValid() || row.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		row = row.Field(i)
+	}
+	return row
+}
+
+// mapColumns returns a column for each key of the rows, sorted by name.
+func mapColumns(rows []reflect.Value) []column {
+	var names []string
+	for _, row := range rows {
+		if !row.IsValid() {
+			continue
+		}
+		for _, key := range row.MapKeys() {
+			if !slices.Contains(names, key.String()) {
+				names = append(names, key.String())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	columns := make([]column, len(names))
+	for i, name := range names {
+		columns[i] = column{
+			name: name,
+			cell: func(row reflect.Value) reflect.Value {
+				if !row.IsValid() {
+					return reflect.Value{}
+				}
+				return row.MapIndex(reflect.ValueOf(name).Convert(row.Type().Key()))
+			},
+		}
+	}
+	return columns
+}
+
+// selectColumns returns the columns with the selected names, in the selected
+// order. Names are matched case-insensitively. If a selected column does not
+// exist, then an error wrapping ErrColumnNotFound is returned.
+func selectColumns(columns []column, selected []string) ([]column, error) {
+	result := make([]column, 0, len(selected))
+	for _, name := range selected {
+		index := slices.IndexFunc(columns, func(col column) bool {
+			return strings.EqualFold(col.name, name)
+		})
+		if index < 0 {
+			names := make([]string, len(columns))
+			for i, col := range columns {
+				names[i] = col.name
+			}
+			return nil, fmt.Errorf("%w: %q, valid columns are %s", ErrColumnNotFound, name, strings.Join(names, ", "))
+		}
+		result = append(result, columns[index])
+	}
+	return result, nil
+}
+
+// formatCell formats the value of a table cell. Nil values are empty, times
+// are formatted as RFC 3339, values implementing fmt.Stringer are formatted
+// with their String method, slices of scalars are joined with commas, and
+// other composite values are formatted as compact JSON.
+func formatCell(rv reflect.Value) string {
+	if rv.IsValid() && rv.CanInterface() {
+		switch v := rv.Interface().(type) {
+		case time.Time:
+			return v.Format(time.RFC3339)
+		case fmt.Stringer:
+			if rv.Kind() != reflect.Pointer || !rv.IsNil() {
+				return v.String()
+			}
+		}
+	}
+
+	rv = indirect(rv)
+	if !rv.IsValid() {
+		return ""
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+
+		cells := make([]string, rv.Len())
+		for i := range rv.Len() {
+			elem := indirect(rv.Index(i))
+			if elem.IsValid() && isComposite(elem.Kind()) {
+				return formatJSONCell(rv)
+			}
+			cells[i] = formatCell(rv.Index(i))
+		}
+		return strings.Join(cells, ",")
+	case reflect.Map, reflect.Struct:
+		return formatJSONCell(rv)
+	}
+
+	return fmt.Sprint(rv.Interface())
+}
+
+// formatJSONCell formats the value of a table cell as compact JSON.
+func formatJSONCell(rv reflect.Value) string {
+	data, err := json.Marshal(rv.Interface())
+	if err != nil {
+		return fmt.Sprint(rv.Interface())
+	}
+	return string(data)
+}
+
+// isComposite returns true if the kind is a composite kind rendered as JSON.
+func isComposite(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
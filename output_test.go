@@ -0,0 +1,273 @@
+package snek_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+	"github.com/ronelliott/snek/output"
+)
+
+type outputTestItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func runOutputTest(t *testing.T, cfg *snek.Config, args []string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	sub, err := snek.NewCommand(
+		snek.WithUse("list"),
+		snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+			return snek.Print(cmd, []outputTestItem{{Name: "a", Count: 1}, {Name: "b", Count: 2}})
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	snek.WithLogOutput(io.Discard)(cfg)
+	err = snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithSubCommand(sub),
+		func(cmd *cobra.Command) error {
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			return nil
+		},
+	)
+	return out.String(), err
+}
+
+func TestWithDefaultOutputFormat(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "table", cfg.DefaultOutputFormat, "The default output format should be table")
+	cfg = snek.NewConfig(snek.WithDefaultOutputFormat("json"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "json", cfg.DefaultOutputFormat, "DefaultOutputFormat should be json")
+}
+
+func TestWithOutputEnvironmentVariableName(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "OUTPUT", cfg.OutputEnvironmentVariableName, "The default output environment variable name should be OUTPUT")
+	cfg = snek.NewConfig(snek.WithOutputEnvironmentVariableName("FORMAT"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "FORMAT", cfg.OutputEnvironmentVariableName, "OutputEnvironmentVariableName should be FORMAT")
+}
+
+func TestWithOutputFlag(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.OutputFlag, "OutputFlag should be false")
+	cfg = snek.NewConfig(snek.WithOutputFlag(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.OutputFlag, "OutputFlag should be true")
+}
+
+func TestRun_OutputFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "default", args: []string{"list"}, expected: "NAME   COUNT\na      1\nb      2\n"},
+		{name: "json", args: []string{"list", "-o", "json"}, expected: "[\n  {\n    \"name\": \"a\",\n    \"count\": 1\n  },\n  {\n    \"name\": \"b\",\n    \"count\": 2\n  }\n]\n"},
+		{name: "jsonl", args: []string{"list", "--output=jsonl"}, expected: "{\"name\":\"a\",\"count\":1}\n{\"name\":\"b\",\"count\":2}\n"},
+		{name: "yaml", args: []string{"list", "-o", "yaml"}, expected: "- name: a\n  count: 1\n- name: b\n  count: 2\n"},
+		{name: "template", args: []string{"list", "-o", "template={{range .}}{{.Name}} {{end}}"}, expected: "a b "},
+		{name: "jsonpath", args: []string{"list", "-o", "jsonpath={[*].count}"}, expected: "1\n2\n"},
+		{name: "columns", args: []string{"list", "--columns", "count", "--no-headers"}, expected: "1\n2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runOutputTest(t, snek.NewConfig(snek.WithOutputFlag(true)), tt.args)
+			require.NoError(t, err, "Run should not return an error")
+			assert.Equal(t, tt.expected, out, "Print should render the value in the selected format")
+		})
+	}
+}
+
+func TestRun_OutputFlag_Invalid(t *testing.T) {
+	_, err := runOutputTest(t, snek.NewConfig(snek.WithOutputFlag(true)), []string{"list", "-o", "xml"})
+	assert.ErrorIs(t, err, output.ErrFormatInvalid, "Run should return ErrFormatInvalid for an invalid format")
+
+	_, err = runOutputTest(t, snek.NewConfig(snek.WithOutputFlag(true)), []string{"list", "--columns", "missing"})
+	assert.ErrorIs(t, err, output.ErrColumnNotFound, "Run should return ErrColumnNotFound for an unknown column")
+
+	_, err = runOutputTest(t, snek.NewConfig(snek.WithOutputFlag(true), snek.WithDefaultOutputFormat("xml")), []string{"list"})
+	assert.ErrorIs(t, err, snek.ErrOutputFormatInvalid, "Run should return ErrOutputFormatInvalid for an invalid default")
+
+	_, err = runOutputTest(t, snek.NewConfig(snek.WithOutputFlag(true), snek.WithOutputEnvironmentVariableName("")), []string{"list"})
+	assert.ErrorIs(t, err, snek.ErrOutputEnvironmentVariableNameEmpty,
+		"Run should return ErrOutputEnvironmentVariableNameEmpty for an empty environment variable name")
+}
+
+func TestRun_OutputFlag_Environment(t *testing.T) {
+	t.Setenv("APP_OUTPUT", "jsonpath={[0].name}")
+	out, err := runOutputTest(t, snek.NewConfig(snek.WithOutputFlag(true), snek.WithEnvironmentVariablePrefix("APP_")),
+		[]string{"list"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, "a\n", out, "The environment variable should set the output format")
+
+	t.Setenv("APP_OUTPUT", "xml")
+	_, err = runOutputTest(t, snek.NewConfig(snek.WithOutputFlag(true), snek.WithEnvironmentVariablePrefix("APP_")),
+		[]string{"list"})
+	assert.ErrorIs(t, err, snek.ErrFlagEnvVarInvalid, "Run should return ErrFlagEnvVarInvalid for an invalid environment variable")
+}
+
+func TestRun_OutputFlag_Duplicate(t *testing.T) {
+	for _, name := range []string{"output", "columns", "no-headers"} {
+		t.Run(name, func(t *testing.T) {
+			var value string
+			err := snek.Run(nil, snek.NewConfig(snek.WithLogOutput(io.Discard), snek.WithOutputFlag(true)),
+				snek.WithUse("app"),
+				snek.WithPersistentFlag(snek.WithStringVar(&value, name, "", "A flag in use.")),
+			)
+			assert.ErrorIs(t, err, snek.ErrFlagDuplicate, "Run should return ErrFlagDuplicate if an output flag is in use")
+		})
+	}
+}
+
+func TestRun_OutputFlag_ShorthandInUse(t *testing.T) {
+	var owner string
+	var root *cobra.Command
+	err := snek.Run([]string{"-o", "me"}, snek.NewConfig(snek.WithLogOutput(io.Discard), snek.WithOutputFlag(true)),
+		snek.WithUse("app"),
+		snek.WithFlag(snek.WithStringVarP(&owner, "owner", "o", "", "The owner.")),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) { root = cmd }),
+	)
+	require.NoError(t, err, "Run should not return an error if the output shorthand is in use")
+	assert.Equal(t, "me", owner, "The shorthand should belong to the flag that already used it")
+	require.NotNil(t, root, "The command should be executed")
+	flag := root.Flags().Lookup("output")
+	require.NotNil(t, flag, "The output flag should be added")
+	assert.Empty(t, flag.Shorthand, "The output flag should not have a shorthand if it is in use")
+}
+
+func TestPrint_WithoutOutputFlag(t *testing.T) {
+	out, err := runOutputTest(t, snek.NewConfig(), []string{"list"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, "NAME   COUNT\na      1\nb      2\n", out, "Print should render a table without the output flag")
+	_, err = runOutputTest(t, snek.NewConfig(), []string{"list", "-o", "json"})
+	assert.Error(t, err, "The output flag should not be added unless enabled")
+}
+
+func captureStandardStreams(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+	capture := func(file **os.File) func() string {
+		r, w, err := os.Pipe()
+		require.NoError(t, err, "Pipe should not return an error")
+		original := *file
+		*file = w
+
+		done := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- string(data)
+		}()
+
+		return func() string {
+			*file = original
+			require.NoError(t, w.Close(), "Close should not return an error")
+			return <-done
+		}
+	}
+
+	stdout := capture(&os.Stdout)
+	stderr := capture(&os.Stderr)
+	fn()
+	return stdout(), stderr()
+}
+
+func TestWithSeparateOutput(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.SeparateOutput, "SeparateOutput should be false")
+	cfg = snek.NewConfig(snek.WithSeparateOutput(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.SeparateOutput, "SeparateOutput should be true")
+}
+
+func TestRun_SeparateOutput(t *testing.T) {
+	tests := []struct {
+		name         string
+		configurator snek.Configurator
+		args         []string
+		logsToStdout bool
+	}{
+		{name: "default", configurator: snek.WithOutputFlag(true), args: []string{}, logsToStdout: true},
+		{name: "separate", configurator: snek.WithSeparateOutput(true), args: []string{}},
+		{name: "json", configurator: snek.WithOutputFlag(true), args: []string{"-o", "json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := captureStandardStreams(t, func() {
+				err := snek.Run(tt.args, snek.NewConfig(
+					tt.configurator,
+					snek.WithDefaultLogLevel("debug"),
+					snek.WithDefaultLogFormat("json"),
+				),
+					snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+						return snek.Print(cmd, map[string]string{"name": "a"})
+					}),
+				)
+				require.NoError(t, err, "Run should not return an error")
+			})
+
+			if tt.logsToStdout {
+				assert.Contains(t, stdout, "Logging initialized.", "Logs should be written to stdout")
+				assert.Empty(t, stderr, "Nothing should be written to stderr")
+				return
+			}
+
+			assert.NotContains(t, stdout, "Logging initialized.", "Logs should not be written to stdout")
+			assert.NotContains(t, stdout, "Debug logging enabled.", "Debug logs should not be written to stdout")
+			assert.Contains(t, stderr, "Logging initialized.", "Logs should be written to stderr")
+			assert.Contains(t, stdout, "a", "The data should be written to stdout")
+		})
+	}
+}
+
+func TestRun_SeparateOutput_LogOutput(t *testing.T) {
+	var logs bytes.Buffer
+	stdout, stderr := captureStandardStreams(t, func() {
+		err := snek.Run([]string{}, snek.NewConfig(snek.WithSeparateOutput(true), snek.WithLogOutput(&logs)),
+			snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+				_, err := io.WriteString(snek.Out(cmd), "data\n")
+				return err
+			}),
+		)
+		require.NoError(t, err, "Run should not return an error")
+	})
+
+	assert.Equal(t, "data\n", stdout, "The data should be written to stdout")
+	assert.Empty(t, stderr, "Nothing should be written to stderr")
+	assert.Contains(t, logs.String(), "Logging initialized.", "Logs should be written to the configured log output")
+}
+
+func TestRun_SeparateOutput_LogSinks(t *testing.T) {
+	stdout, stderr := captureStandardStreams(t, func() {
+		err := snek.Run([]string{}, snek.NewConfig(
+			snek.WithSeparateOutput(true),
+			snek.WithLogOutput(io.Discard),
+			snek.WithLogSink(snek.LogSink{Output: os.Stdout, Format: "json"}),
+		),
+			snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+				_, err := io.WriteString(snek.Out(cmd), "data\n")
+				return err
+			}),
+		)
+		require.NoError(t, err, "Run should not return an error")
+	})
+
+	assert.Equal(t, "data\n", stdout, "Only the data should be written to stdout")
+	assert.Contains(t, stderr, "Logging initialized.", "The logs of the sink should be written to stderr")
+}
@@ -0,0 +1,137 @@
+package snek
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ronelliott/snek/prompt"
+)
+
+// WithSecretFlag marks the specified flags of the command as holding a secret,
+// so their values are not echoed when they are prompted for. The flags must be
+// added to the command before they are marked. If a flag does not exist, then
+// an error is returned.
+func WithSecretFlag(names ...string) Initializer {
+	return func(cmd *Command) error {
+		for _, name := range names {
+			flags := cmd.Flags()
+			if cmd.PersistentFlags().Lookup(name) != nil {
+				flags = cmd.PersistentFlags()
+			}
+
+			if err := flags.SetAnnotation(name, FlagAnnotationSecret, []string{"true"}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Prompter returns the prompter of the command, which prompts on the input of
+// the command and writes the questions to its error output, so the data output
+// of the command is not affected. The prompter is not interactive when the
+// input is not a terminal, or when the `--no-input` flag added by Run with
+// WithPrompt is set.
+func Prompter(cmd *Command) *prompt.Prompter {
+	ext := extensionsFor(cmd.Root())
+	ext.mu.Lock()
+	defer ext.mu.Unlock()
+
+	if ext.prompter == nil {
+		ext.prompter = prompt.New(cmd.InOrStdin(), cmd.ErrOrStderr())
+	}
+	return ext.prompter
+}
+
+// disablePrompts makes the prompter of the command tree non-interactive.
+func disablePrompts(cmd *Command) {
+	ext := extensionsFor(cmd.Root())
+	ext.mu.Lock()
+	defer ext.mu.Unlock()
+	ext.prompter = prompt.NonInteractive()
+}
+
+// promptRequiredFlags prompts for the value of every required flag of the
+// command that is neither set on the command line nor by its environment
+// variable. Enum flags are prompted with a selection of their choices, bool
+// flags with a confirmation, secret flags without echoing the answer, and any
+// other flags with a line of text. Required flags set by their environment
+// variable are marked as changed, so cobra does not report them as missing.
+// Nothing is prompted when the prompter is not interactive, in which case
+// cobra reports the missing flags.
+func promptRequiredFlags(cmd *Command, prompter *prompt.Prompter) error {
+	var missing []*pflag.Flag
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if required := flag.Annotations[cobra.BashCompOneRequiredFlag]; len(required) == 0 || required[0] != "true" {
+			return
+		}
+
+		if flag.Changed {
+			return
+		}
+
+		if environmentVariableSet(flag) {
+			flag.Changed = true
+			return
+		}
+
+		missing = append(missing, flag)
+	})
+
+	if !prompter.Interactive() {
+		return nil
+	}
+
+	for _, flag := range missing {
+		label := flag.Usage
+		if label == "" {
+			label = flag.Name
+		}
+
+		var value string
+		var err error
+		switch {
+		case flag.Annotations[FlagAnnotationSecret] != nil:
+			for value == "" && err == nil {
+				value, err = prompter.Password(label)
+			}
+		case flag.Annotations[FlagAnnotationEnum] != nil:
+			value, err = prompter.Select(label, flag.Annotations[FlagAnnotationEnum], flag.DefValue)
+		case flag.Value.Type() == "bool":
+			var confirmed bool
+			confirmed, err = prompter.Confirm(label, flag.DefValue == "true")
+			value = "false"
+			if confirmed {
+				value = "true"
+			}
+		default:
+			for value == "" && err == nil {
+				value, err = prompter.Text(label, flag.DefValue)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := cmd.Flags().Set(flag.Name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// environmentVariableSet returns true if the environment variable of the flag
+// is set.
+func environmentVariableSet(flag *pflag.Flag) bool {
+	for _, name := range flag.Annotations[FlagAnnotationEnvironmentVariable] {
+		if _, ok := os.LookupEnv(name); ok {
+			return true
+		}
+	}
+	return false
+}
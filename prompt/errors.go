@@ -0,0 +1,13 @@
+package prompt
+
+import "errors"
+
+var (
+	// ErrNonInteractive is returned when a prompter that is not interactive is
+	// asked to prompt for a value.
+	ErrNonInteractive = errors.New("cannot prompt in a non-interactive session")
+
+	// ErrNoChoices is returned when a prompter is asked to select from an empty
+	// list of choices.
+	ErrNoChoices = errors.New("no choices to select from")
+)
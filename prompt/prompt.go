@@ -0,0 +1,196 @@
+// Package prompt asks the user for values on a terminal. A Prompter reads
+// answers from an input and writes questions to an output, both of which can
+// be injected, so commands that prompt are fully testable. When the input is
+// not a terminal, such as when it is piped or in a CI environment, the
+// prompter is not interactive and every prompt returns ErrNonInteractive.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompter prompts for values by writing questions to an output and reading
+// the answers from an input.
+type Prompter struct {
+	in          *bufio.Reader
+	file        *os.File
+	out         io.Writer
+	interactive bool
+}
+
+// New creates a prompter reading answers from the input and writing questions
+// to the output. The prompter is interactive when the input is a terminal, or
+// when it is not a file at all, such as an input injected by a test.
+func New(in io.Reader, out io.Writer) *Prompter {
+	file, _ := in.(*os.File)
+	return &Prompter{
+		in:          bufio.NewReader(in),
+		file:        file,
+		out:         out,
+		interactive: IsInteractive(in),
+	}
+}
+
+// NonInteractive creates a prompter that is not interactive, so every prompt
+// returns ErrNonInteractive.
+func NonInteractive() *Prompter {
+	return &Prompter{}
+}
+
+// IsInteractive returns true if the input is a terminal, or if it is not a file
+// at all, such as an input injected by a test.
+func IsInteractive(in io.Reader) bool {
+	file, ok := in.(*os.File)
+	if !ok {
+		return in != nil
+	}
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// Interactive returns true if the prompter can prompt for values.
+func (p *Prompter) Interactive() bool {
+	return p.interactive
+}
+
+// Text prompts for a line of text with the label. If the answer is empty, then
+// the default value is returned.
+func (p *Prompter) Text(label, defaultValue string) (string, error) {
+	if !p.interactive {
+		return "", fmt.Errorf("%w: %s", ErrNonInteractive, label)
+	}
+
+	if defaultValue != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, defaultValue)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", label)
+	}
+
+	answer, err := p.readLine()
+	if err != nil {
+		return "", err
+	}
+
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+// Password prompts for a secret with the label. When the input is a terminal,
+// the answer is not echoed.
+func (p *Prompter) Password(label string) (string, error) {
+	if !p.interactive {
+		return "", fmt.Errorf("%w: %s", ErrNonInteractive, label)
+	}
+
+	fmt.Fprintf(p.out, "%s: ", label)
+	if p.file != nil && term.IsTerminal(int(p.file.Fd())) {
+		password, err := term.ReadPassword(int(p.file.Fd()))
+		fmt.Fprintln(p.out)
+		return string(password), err
+	}
+
+	return p.readLine()
+}
+
+// Confirm prompts for a yes or no answer with the label. If the answer is
+// empty, then the default value is returned. The prompt is repeated until the
+// answer is valid.
+func (p *Prompter) Confirm(label string, defaultValue bool) (bool, error) {
+	if !p.interactive {
+		return false, fmt.Errorf("%w: %s", ErrNonInteractive, label)
+	}
+
+	options := "y/N"
+	if defaultValue {
+		options = "Y/n"
+	}
+
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, options)
+		answer, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "":
+			return defaultValue, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+
+		fmt.Fprintln(p.out, "Please answer yes or no.")
+	}
+}
+
+// Select prompts for one of the choices with the label. The choices are listed
+// with a number, and either the number or the choice itself is accepted as the
+// answer. If the answer is empty, then the default value is returned when it
+// is not empty. The prompt is repeated until the answer is valid.
+func (p *Prompter) Select(label string, choices []string, defaultValue string) (string, error) {
+	if !p.interactive {
+		return "", fmt.Errorf("%w: %s", ErrNonInteractive, label)
+	}
+
+	if len(choices) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrNoChoices, label)
+	}
+
+	fmt.Fprintf(p.out, "%s:\n", label)
+	for i, choice := range choices {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, choice)
+	}
+
+	for {
+		if defaultValue != "" {
+			fmt.Fprintf(p.out, "Choose [%s]: ", defaultValue)
+		} else {
+			fmt.Fprint(p.out, "Choose: ")
+		}
+
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+
+		if answer == "" && defaultValue != "" {
+			return defaultValue, nil
+		}
+
+		if slices.Contains(choices, answer) {
+			return answer, nil
+		}
+
+		if index, err := strconv.Atoi(answer); err == nil && index >= 1 && index <= len(choices) {
+			return choices[index-1], nil
+		}
+
+		fmt.Fprintf(p.out, "Please choose a number between 1 and %d.\n", len(choices))
+	}
+}
+
+// readLine reads a line from the input without the trailing line ending. If
+// the input ends before a line is read, then io.ErrUnexpectedEOF is returned.
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		if errors.Is(err, io.EOF) {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,136 @@
+package prompt_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek/prompt"
+)
+
+func newPrompter(input string) (*prompt.Prompter, *bytes.Buffer) {
+	var out bytes.Buffer
+	return prompt.New(strings.NewReader(input), &out), &out
+}
+
+func TestIsInteractive(t *testing.T) {
+	assert.True(t, prompt.IsInteractive(strings.NewReader("")), "An injected input should be interactive")
+	assert.False(t, prompt.IsInteractive(nil), "A nil input should not be interactive")
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err, "Pipe should not return an error")
+	defer r.Close()
+	defer w.Close()
+	assert.False(t, prompt.IsInteractive(r), "A pipe should not be interactive")
+	assert.False(t, prompt.New(r, io.Discard).Interactive(), "A prompter reading a pipe should not be interactive")
+}
+
+func TestNonInteractive(t *testing.T) {
+	p := prompt.NonInteractive()
+	assert.False(t, p.Interactive(), "NonInteractive should not be interactive")
+
+	_, err := p.Text("Name", "")
+	assert.ErrorIs(t, err, prompt.ErrNonInteractive, "Text should return ErrNonInteractive")
+	_, err = p.Password("Password")
+	assert.ErrorIs(t, err, prompt.ErrNonInteractive, "Password should return ErrNonInteractive")
+	_, err = p.Confirm("Continue?", true)
+	assert.ErrorIs(t, err, prompt.ErrNonInteractive, "Confirm should return ErrNonInteractive")
+	_, err = p.Select("Color", []string{"red"}, "")
+	assert.ErrorIs(t, err, prompt.ErrNonInteractive, "Select should return ErrNonInteractive")
+}
+
+func TestPrompter_Text(t *testing.T) {
+	p, out := newPrompter("alice\n\n")
+	value, err := p.Text("Name", "bob")
+	require.NoError(t, err, "Text should not return an error")
+	assert.Equal(t, "alice", value, "Text should return the answer")
+
+	value, err = p.Text("Name", "bob")
+	require.NoError(t, err, "Text should not return an error")
+	assert.Equal(t, "bob", value, "Text should return the default for an empty answer")
+	assert.Equal(t, "Name [bob]: Name [bob]: ", out.String(), "Text should write the label and default")
+
+	_, err = p.Text("Name", "")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "Text should return ErrUnexpectedEOF when the input ends")
+}
+
+func TestPrompter_Text_NoTrailingNewline(t *testing.T) {
+	p, _ := newPrompter("alice")
+	value, err := p.Text("Name", "")
+	require.NoError(t, err, "Text should not return an error")
+	assert.Equal(t, "alice", value, "Text should return the last line without a line ending")
+}
+
+func TestPrompter_Password(t *testing.T) {
+	p, out := newPrompter("secret\r\n")
+	value, err := p.Password("Password")
+	require.NoError(t, err, "Password should not return an error")
+	assert.Equal(t, "secret", value, "Password should return the answer")
+	assert.Equal(t, "Password: ", out.String(), "Password should write the label")
+}
+
+func TestPrompter_Confirm(t *testing.T) {
+	tests := []struct {
+		input        string
+		defaultValue bool
+		expected     bool
+	}{
+		{input: "y\n", expected: true},
+		{input: "YES\n", expected: true},
+		{input: "n\n", defaultValue: true, expected: false},
+		{input: "\n", defaultValue: true, expected: true},
+		{input: "\n", expected: false},
+		{input: "maybe\nyes\n", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p, _ := newPrompter(tt.input)
+			value, err := p.Confirm("Continue?", tt.defaultValue)
+			require.NoError(t, err, "Confirm should not return an error")
+			assert.Equal(t, tt.expected, value, "Confirm should return the answer")
+		})
+	}
+
+	p, out := newPrompter("maybe\nno\n")
+	_, err := p.Confirm("Continue?", false)
+	require.NoError(t, err, "Confirm should not return an error")
+	assert.Equal(t, "Continue? [y/N]: Please answer yes or no.\nContinue? [y/N]: ", out.String(),
+		"Confirm should repeat the prompt for an invalid answer")
+}
+
+func TestPrompter_Select(t *testing.T) {
+	choices := []string{"red", "green", "blue"}
+	tests := []struct {
+		input        string
+		defaultValue string
+		expected     string
+	}{
+		{input: "2\n", expected: "green"},
+		{input: "blue\n", expected: "blue"},
+		{input: "\n", defaultValue: "red", expected: "red"},
+		{input: "7\npurple\n\n1\n", expected: "red"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p, _ := newPrompter(tt.input)
+			value, err := p.Select("Color", choices, tt.defaultValue)
+			require.NoError(t, err, "Select should not return an error")
+			assert.Equal(t, tt.expected, value, "Select should return the chosen value")
+		})
+	}
+
+	p, out := newPrompter("1\n")
+	_, err := p.Select("Color", choices, "")
+	require.NoError(t, err, "Select should not return an error")
+	assert.Equal(t, "Color:\n  1) red\n  2) green\n  3) blue\nChoose: ", out.String(), "Select should list the choices")
+
+	_, err = p.Select("Color", nil, "")
+	assert.ErrorIs(t, err, prompt.ErrNoChoices, "Select should return ErrNoChoices without choices")
+}
@@ -0,0 +1,147 @@
+package snek_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+type promptTestValues struct {
+	name     string
+	password string
+	color    string
+	force    bool
+}
+
+func runPromptTest(t *testing.T, cfg *snek.Config, input string, args []string) (*promptTestValues, string, error) {
+	t.Helper()
+	values := &promptTestValues{}
+	var stderr bytes.Buffer
+	snek.WithLogOutput(io.Discard)(cfg)
+	err := snek.Run(args, cfg,
+		snek.WithUse("app"),
+		snek.WithFlag(
+			snek.WithStringVar(&values.name, "name", "", "Name"),
+			snek.WithStringVarE(&values.password, "password", "APP_PASSWORD", "", "Password"),
+			snek.WithEnumVar(&values.color, "color", "", []string{"red", "green"}, "Color"),
+			snek.WithBoolVar(&values.force, "force", false, "Force"),
+		),
+		snek.WithRequiredFlag("name", "password", "color", "force"),
+		snek.WithSecretFlag("password"),
+		snek.WithRunE(func(*cobra.Command, []string) error {
+			return nil
+		}),
+		func(cmd *cobra.Command) error {
+			cmd.SetIn(strings.NewReader(input))
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(&stderr)
+			return nil
+		},
+	)
+	return values, stderr.String(), err
+}
+
+func TestWithPrompt(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.Prompt, "Prompt should be false")
+	cfg = snek.NewConfig(snek.WithPrompt(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.Prompt, "Prompt should be true")
+}
+
+func TestWithSecretFlag(t *testing.T) {
+	cmd, err := snek.NewCommand(
+		snek.WithUse("app"),
+		snek.WithFlag(snek.WithStringVar(new(string), "token", "", "The token.")),
+		snek.WithSecretFlag("token"),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+	assert.Equal(t, []string{"true"}, cmd.Flags().Lookup("token").Annotations[snek.FlagAnnotationSecret],
+		"WithSecretFlag should annotate the flag")
+
+	_, err = snek.NewCommand(snek.WithUse("app"), snek.WithSecretFlag("missing"))
+	assert.Error(t, err, "WithSecretFlag should return an error for a missing flag")
+}
+
+func TestRun_Prompt(t *testing.T) {
+	values, stderr, err := runPromptTest(t, snek.NewConfig(snek.WithPrompt(true)),
+		"3\ngreen\nyes\n\nalice\n\nhunter2\n", []string{})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, "alice", values.name, "The name should be prompted for until it is not empty")
+	assert.Equal(t, "hunter2", values.password, "The password should be prompted for until it is not empty")
+	assert.Equal(t, "green", values.color, "The color should be selected from the choices")
+	assert.True(t, values.force, "The force flag should be confirmed")
+	assert.Contains(t, stderr, "Password: ", "The password prompt should be written to the error output")
+	assert.Contains(t, stderr, "  1) red\n  2) green\n", "The choices should be written to the error output")
+	assert.Contains(t, stderr, "Force [y/N]: ", "The confirmation should be written to the error output")
+}
+
+func TestRun_Prompt_SetFlags(t *testing.T) {
+	t.Setenv("APP_PASSWORD", "secret")
+	values, stderr, err := runPromptTest(t, snek.NewConfig(snek.WithPrompt(true)),
+		"red\n", []string{"--name", "bob", "--force"})
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, "bob", values.name, "The name should be set by the flag")
+	assert.Equal(t, "secret", values.password, "The password should be set by the environment variable")
+	assert.Equal(t, "red", values.color, "The color should be prompted for")
+	assert.NotContains(t, stderr, "Name", "Flags that are set should not be prompted for")
+	assert.NotContains(t, stderr, "Password", "Flags set by environment variables should not be prompted for")
+}
+
+func TestRun_Prompt_NoInput(t *testing.T) {
+	_, stderr, err := runPromptTest(t, snek.NewConfig(snek.WithPrompt(true)),
+		"1\ny\nalice\nhunter2\n", []string{"--no-input"})
+	require.Error(t, err, "Run should return an error for the missing required flags")
+	assert.Contains(t, err.Error(), "required flag(s)", "Run should report the missing required flags")
+	assert.NotContains(t, stderr, "Name", "Nothing should be prompted for with --no-input")
+
+	t.Setenv("NO_INPUT", "true")
+	_, _, err = runPromptTest(t, snek.NewConfig(snek.WithPrompt(true)), "1\ny\nalice\nhunter2\n", []string{})
+	assert.Error(t, err, "Run should return an error when prompts are disabled by the environment variable")
+}
+
+func TestWithNoInputEnvironmentVariableName(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Equal(t, "NO_INPUT", cfg.NoInputEnvironmentVariableName,
+		"The default no input environment variable name should be NO_INPUT")
+	cfg = snek.NewConfig(snek.WithNoInputEnvironmentVariableName("NON_INTERACTIVE"))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, "NON_INTERACTIVE", cfg.NoInputEnvironmentVariableName,
+		"NoInputEnvironmentVariableName should be NON_INTERACTIVE")
+}
+
+func TestRun_Prompt_NoInputEnvironmentVariableName(t *testing.T) {
+	t.Setenv("APP_NON_INTERACTIVE", "true")
+	_, stderr, err := runPromptTest(t, snek.NewConfig(
+		snek.WithPrompt(true),
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithNoInputEnvironmentVariableName("NON_INTERACTIVE"),
+	), "1\ny\nalice\nhunter2\n", []string{})
+	require.Error(t, err, "Run should return an error when prompts are disabled by the renamed environment variable")
+	assert.NotContains(t, stderr, "Name", "Nothing should be prompted for when prompts are disabled")
+
+	_, _, err = runPromptTest(t, snek.NewConfig(snek.WithPrompt(true), snek.WithNoInputEnvironmentVariableName("")),
+		"", []string{})
+	assert.ErrorIs(t, err, snek.ErrNoInputEnvironmentVariableNameEmpty,
+		"Run should return ErrNoInputEnvironmentVariableNameEmpty for an empty environment variable name")
+}
+
+func TestRun_Prompt_Disabled(t *testing.T) {
+	_, stderr, err := runPromptTest(t, snek.NewConfig(), "1\ny\nalice\nhunter2\n", []string{})
+	require.Error(t, err, "Run should return an error for the missing required flags")
+	assert.NotContains(t, stderr, "Name", "Nothing should be prompted for without WithPrompt")
+}
+
+func TestRun_Prompt_EndOfInput(t *testing.T) {
+	_, _, err := runPromptTest(t, snek.NewConfig(snek.WithPrompt(true)), "1\n", []string{})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "Run should return ErrUnexpectedEOF when the input ends")
+}
@@ -0,0 +1,230 @@
+package snek
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// provider lazily constructs and caches a single value for the command it is
+// registered on.
+type provider struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// typ is the type of value provided.
+	typ reflect.Type
+
+	// construct creates the value provided.
+	construct func(*Command) (any, error)
+
+	// constructing is true while the value is being constructed, and done is
+	// closed once it is, whether the construction succeeded, failed or
+	// panicked.
+	constructing bool
+	done         chan struct{}
+
+	// constructed is true when value holds a constructed value.
+	constructed bool
+
+	// value is the cached value.
+	value any
+}
+
+// providerChainKey is the context key of the types being constructed by the
+// providers of the resolution a command belongs to.
+type providerChainKey struct{}
+
+// providerChain returns the types being constructed by the resolution the
+// context belongs to, in the order they were requested.
+func providerChain(ctx context.Context) []reflect.Type {
+	if ctx == nil {
+		return nil
+	}
+	chain, _ := ctx.Value(providerChainKey{}).([]reflect.Type)
+	return chain
+}
+
+// get returns the cached value of the provider, constructing it using the
+// specified command if it has not been constructed yet. Callers waiting for a
+// construction in progress retry it if it fails. When the value is
+// constructed, a shutdown hook is registered that clears the cached value and
+// closes it if it implements io.Closer.
+//
+// The chain is the types being constructed by the resolution that requested
+// the value. If the type of the provider is in the chain, then the value was
+// requested again by its own construction, directly or through other
+// providers, and an error wrapping ErrProviderCycle is returned instead of
+// waiting for the construction forever.
+func (p *provider) get(cmd *Command, chain []reflect.Type) (any, error) {
+	if slices.Contains(chain, p.typ) {
+		return nil, fmt.Errorf("%w: %s", ErrProviderCycle, p.typ)
+	}
+
+	p.mu.Lock()
+	for p.constructing {
+		done := p.done
+		p.mu.Unlock()
+		<-done
+		p.mu.Lock()
+	}
+
+	if p.constructed {
+		defer p.mu.Unlock()
+		return p.value, nil
+	}
+
+	p.constructing, p.done = true, make(chan struct{})
+	p.mu.Unlock()
+
+	value, err := p.constructValue(cmd, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	OnShutdown(cmd, func(context.Context) error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		value := p.value
+		p.value = nil
+		p.constructed = false
+
+		if closer, ok := value.(io.Closer); ok {
+			return closer.Close()
+		}
+
+		return nil
+	})
+
+	return value, nil
+}
+
+// constructValue constructs the value and caches it if the construction
+// succeeds. The construction is marked as finished even if the provider
+// panics, so callers waiting for it do not wait forever.
+//
+// The provider is passed a copy of the command whose context records the
+// chain along with the type of the provider, so the values it requests with
+// Get are resolved as part of the same chain without changing the context of
+// the command seen by other goroutines.
+func (p *provider) constructValue(cmd *Command, chain []reflect.Type) (value any, err error) {
+	defer func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.constructing = false
+		close(p.done)
+	}()
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// The flags of the command are created before it is copied, so the copy
+	// shares them and the extensions they hold.
+	cmd.Flags()
+	resolving := *cmd
+	resolving.SetContext(context.WithValue(ctx, providerChainKey{}, append(slices.Clip(chain), p.typ)))
+
+	value, err = p.construct(&resolving)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.value, p.constructed = value, true
+	return value, nil
+}
+
+// WithProvider registers a provider for values of type T on the command. The
+// provider is called the first time a value of type T is requested with Get by
+// the command or any of its descendants, and is passed a copy of the command
+// that requested the value. The context of the copy records the values being
+// constructed, so providers should request the values they depend on with Get
+// on the command they are passed. The value is cached until the command tree
+// finishes executing, at which point it is closed if it implements io.Closer.
+// If the provider returns an error or panics, then it is called again on the
+// next request.
+//
+// If a provider for type T is already registered on the command, then an error
+// wrapping ErrProviderDuplicate is returned.
+func WithProvider[T any](construct func(cmd *Command) (T, error)) Initializer {
+	return func(cmd *Command) error {
+		typ := reflect.TypeFor[T]()
+
+		ext := extensionsFor(cmd)
+		ext.mu.Lock()
+		defer ext.mu.Unlock()
+
+		if _, ok := ext.providers[typ]; ok {
+			return fmt.Errorf("%w: %s", ErrProviderDuplicate, typ)
+		}
+
+		if ext.providers == nil {
+			ext.providers = map[reflect.Type]*provider{}
+		}
+
+		ext.providers[typ] = &provider{
+			typ: typ,
+			construct: func(cmd *Command) (any, error) {
+				return construct(cmd)
+			},
+		}
+
+		return nil
+	}
+}
+
+// Get returns the value of type T from the provider registered on the nearest
+// of the command or its ancestors. The value is constructed on first use and
+// cached until the command tree finishes executing.
+//
+// If no provider is registered for type T, then an error wrapping
+// ErrProviderNotFound is returned. If the provider requests a value of type T
+// while constructing it, directly or through other providers, then an error
+// wrapping ErrProviderCycle is returned. If the provider returns an error, then that
+// error is returned and the provider is called again on the next request.
+func Get[T any](cmd *Command) (T, error) {
+	var zero T
+	typ := reflect.TypeFor[T]()
+
+	p := lookupProvider(cmd, typ)
+	if p == nil {
+		return zero, fmt.Errorf("%w: %s", ErrProviderNotFound, typ)
+	}
+
+	value, err := p.get(cmd, providerChain(cmd.Context()))
+	if err != nil {
+		return zero, err
+	}
+
+	// A nil value of an interface type cannot be asserted to the type, in
+	// which case the zero value is returned.
+	typed, _ := value.(T)
+	return typed, nil
+}
+
+// lookupProvider returns the provider of the specified type registered on the
+// nearest of the command or its ancestors, or nil if none is registered.
+func lookupProvider(cmd *Command, typ reflect.Type) *provider {
+	for current := cmd; current != nil; current = current.Parent() {
+		ext := lookupExtensions(current)
+		if ext == nil {
+			continue
+		}
+
+		ext.mu.Lock()
+		p, ok := ext.providers[typ]
+		ext.mu.Unlock()
+
+		if ok {
+			return p
+		}
+	}
+
+	return nil
+}
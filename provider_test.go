@@ -0,0 +1,207 @@
+package snek_test
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+type testClient struct {
+	name   string
+	closed bool
+}
+
+func (c *testClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWithProvider_Duplicate(t *testing.T) {
+	provide := func(*cobra.Command) (*testClient, error) { return &testClient{}, nil }
+	cmd, err := snek.NewCommand(snek.WithProvider(provide), snek.WithProvider(provide))
+	assert.ErrorIs(t, err, snek.ErrProviderDuplicate,
+		"NewCommand should return an error if a provider is registered twice for the same type")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+func TestGet_NotFound(t *testing.T) {
+	cmd, err := snek.NewCommand()
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	client, err := snek.Get[*testClient](cmd)
+	assert.ErrorIs(t, err, snek.ErrProviderNotFound,
+		"Get should return an error if no provider is registered")
+	assert.Nil(t, client, "Get should return the zero value")
+}
+
+func TestGet_ProviderError(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithProvider(func(*cobra.Command) (*testClient, error) {
+		return nil, assert.AnError
+	}))
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	_, err = snek.Get[*testClient](cmd)
+	assert.ErrorIs(t, err, assert.AnError, "Get should return the error returned by the provider")
+}
+
+func TestGet_Lifecycle(t *testing.T) {
+	constructed := 0
+	var clients []*testClient
+
+	subCmd, err := snek.NewCommand(
+		snek.WithUse("sub"),
+		snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+			for range 2 {
+				client, err := snek.Get[*testClient](cmd)
+				if err != nil {
+					return err
+				}
+				clients = append(clients, client)
+			}
+			return nil
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	err = snek.Run([]string{"sub"}, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithUse("root"),
+		snek.WithProvider(func(cmd *cobra.Command) (*testClient, error) {
+			constructed++
+			return &testClient{name: cmd.Name()}, nil
+		}),
+		snek.WithSubCommand(subCmd),
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	assert.Equal(t, 1, constructed, "The provider should only be called once per execution")
+	require.Len(t, clients, 2, "Get should be called twice")
+	assert.Same(t, clients[0], clients[1], "Get should return the cached value")
+	assert.Equal(t, "sub", clients[0].name, "The provider should be passed the requesting command")
+	assert.True(t, clients[0].closed, "The value should be closed once the command has executed")
+}
+
+func TestGet_NilInterface(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithProvider(func(*cobra.Command) (io.Closer, error) {
+		return nil, nil
+	}))
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	closer, err := snek.Get[io.Closer](cmd)
+	require.NoError(t, err, "Get should not return an error")
+	assert.Nil(t, closer, "Get should return the nil value returned by the provider")
+}
+
+func TestGet_Cycle(t *testing.T) {
+	type first struct{}
+	type second struct{}
+
+	tests := []struct {
+		name         string
+		initializers []snek.Initializer
+	}{
+		{
+			name: "direct",
+			initializers: []snek.Initializer{
+				snek.WithProvider(func(cmd *cobra.Command) (*first, error) {
+					return snek.Get[*first](cmd)
+				}),
+			},
+		},
+		{
+			name: "indirect",
+			initializers: []snek.Initializer{
+				snek.WithProvider(func(cmd *cobra.Command) (*first, error) {
+					if _, err := snek.Get[*second](cmd); err != nil {
+						return nil, err
+					}
+					return &first{}, nil
+				}),
+				snek.WithProvider(func(cmd *cobra.Command) (*second, error) {
+					if _, err := snek.Get[*first](cmd); err != nil {
+						return nil, err
+					}
+					return &second{}, nil
+				}),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd, err := snek.NewCommand(test.initializers...)
+			require.NoError(t, err, "NewCommand should not return an error")
+
+			value, err := snek.Get[*first](cmd)
+			assert.ErrorIs(t, err, snek.ErrProviderCycle, "Get should return an error for a dependency cycle")
+			assert.Nil(t, value, "Get should return the zero value")
+		})
+	}
+}
+
+func TestGet_Concurrent(t *testing.T) {
+	var constructed atomic.Int32
+	release := make(chan struct{})
+	cmd, err := snek.NewCommand(snek.WithProvider(func(*cobra.Command) (*testClient, error) {
+		constructed.Add(1)
+		<-release
+		return &testClient{}, nil
+	}))
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	var wg sync.WaitGroup
+	clients := make([]*testClient, 5)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := snek.Get[*testClient](cmd)
+			assert.NoError(t, err, "Get should not return an error")
+			clients[i] = client
+		}()
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), constructed.Load(), "The provider should be called once")
+	for _, client := range clients {
+		assert.Same(t, clients[0], client, "Every caller should get the same value")
+	}
+}
+
+func TestGet_Panic(t *testing.T) {
+	var calls atomic.Int32
+	cmd, err := snek.NewCommand(snek.WithProvider(func(*cobra.Command) (*testClient, error) {
+		if calls.Add(1) == 1 {
+			panic("unavailable")
+		}
+		return &testClient{}, nil
+	}))
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	assert.PanicsWithValue(t, "unavailable", func() {
+		_, _ = snek.Get[*testClient](cmd)
+	}, "Get should propagate the panic of the provider")
+
+	done := make(chan error)
+	go func() {
+		_, err := snek.Get[*testClient](cmd)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "Get on another goroutine should construct the value again")
+	case <-time.After(time.Second):
+		t.Fatal("Get on another goroutine should not wait for the panicked construction")
+	}
+
+	client, err := snek.Get[*testClient](cmd)
+	assert.NoError(t, err, "Get on the same goroutine should not report a cycle after a panic")
+	assert.NotNil(t, client, "Get should return the constructed value")
+}
@@ -1,12 +1,28 @@
 package snek
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
 	"os"
+	"os/signal"
+	"slices"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
+const (
+	// dryRunFlagUsage is the help text of the `--dry-run` flag.
+	dryRunFlagUsage = "Show what would be done without making any changes."
+
+	// noInputFlagUsage is the help text of the `--no-input` flag.
+	noInputFlagUsage = "Disable interactive prompts."
+)
+
 // RunExit calls Run with the given initializers and then exits with a status
 // code of 1 if an error is returned from Run.
 func RunExit(cfg *Config, initializers ...Initializer) {
@@ -57,6 +73,34 @@ func RunExit(cfg *Config, initializers ...Initializer) {
 // snek.WithEnvironmentVariablePrefix. The default environment variable prefix
 // is an empty string.
 //
+// Logs are written to a file instead of the log output when the `--log-file`
+// persistent flag added with snek.WithLogFileFlag or the `LOG_FILE`
+// environment variable is set, or when a file is configured with
+// snek.WithLogFile. Log files are rotated as configured
+// with snek.WithLogFileRotation, and are closed once the shutdown hooks have
+// run.
+//
+// Logs are also written to every sink added with snek.WithLogSink, each in its
+// own format and at its own minimum level.
+//
+// Logs are sampled by level as configured with snek.WithLogSampling, or with the
+// `--log-sampling` persistent flag added when snek.WithLogSamplingFlag is
+// enabled. Repeated logs are suppressed and summarized when a window is set with
+// snek.WithLogDeduplication.
+//
+// Logs are enriched with the caller, the hostname, the process ID, the path of
+// the executing command and the version when enabled with snek.WithLogCaller,
+// snek.WithLogHostname, snek.WithLogPID, snek.WithLogCommand and
+// snek.WithLogVersion, or with the `LOG_CALLER`, `LOG_HOSTNAME`, `LOG_PID`,
+// `LOG_COMMAND` and `LOG_VERSION` environment variables, which are renamed
+// with snek.WithLogCallerEnvironmentVariableName and the like.
+//
+// If snek.WithSlogHandler is enabled, then the default log/slog logger writes
+// to the logger created by Run while the command executes, so logs written with
+// log/slog honor the log level, format, output and fields. Like
+// snek.WithGlobalLogger, this changes process-wide state, so it is disabled by
+// default; snek.Slog returns a log/slog logger for the command without it.
+//
 // The default log format is `formatted` and the default log level is `info`. The
 // default log output is `os.Stdout`. To change the default log format, level,
 // or output, use the following initializers:
@@ -65,12 +109,60 @@ func RunExit(cfg *Config, initializers ...Initializer) {
 //	snek.WithDefaultLogLevel
 //	snek.WithLogOutput
 //
+// If snek.WithCompletionCommand is enabled, then a `completion` command is added
+// to the root command that generates shell autocompletion scripts.
+//
+// If snek.WithOutputFlag is enabled, then the `--output`/`-o`, `--columns` and
+// `--no-headers` persistent flags are added to the root command, which select
+// how snek.Print renders values.
+//
+// Logs are written to os.Stderr instead of os.Stdout when snek.WithSeparateOutput
+// is enabled, or when the output format selected with the `--output` flag is
+// not a table, so the data printed by a command with snek.Print or snek.Out is
+// never interleaved with logs.
+//
+// If snek.WithPrompt is enabled, then the value of every missing required flag
+// of the executing command is prompted for when the input is a terminal, and
+// the `--no-input` persistent flag is added to the root command to disable
+// prompts.
+//
+// If snek.WithDryRunFlag is enabled, then the `--dry-run` persistent flag is
+// added to the root command. When it is set, snek.IsDryRun returns true for the
+// context of the executing command, and every log line has a `dry_run` field
+// set to true.
+//
+// Commands that ask for confirmation with snek.WithConfirmation can skip it
+// with the `ASSUME_YES` environment variable, prefixed with the environment
+// variable prefix, as well as with their `--yes` flag.
+//
+// If snek.WithHelpRenderer is enabled, then the help and usage of every command
+// are rendered with the snek help renderer.
+//
+// When an unknown flag is used, the error suggests the closest known flags. If
+// snek.WithEnvironmentVariableCheck is enabled, then a warning is logged for
+// every environment variable with the environment variable prefix that is not
+// known to snek.
+//
+// Any middleware configured with snek.WithGlobalMiddleware is applied to every
+// runnable command in the generated command tree.
+//
 // Logging is setup using a PersistentPreRunE hook on the root command. If an
-// error occurs while setting up logging, it is returned from Run.
+// error occurs while setting up logging, it is returned from Run. A logger is
+// created for each call to Run, which commands retrieve with snek.Logger or
+// with zerolog.Ctx from the context of the command, so concurrent calls to Run
+// do not overwrite each other's logging. The global zerolog logger and level
+// are only set when snek.WithGlobalLogger is enabled.
+//
+// The command is executed with a context that is cancelled when one of the
+// signals configured with snek.WithShutdownSignals is received. Once the
+// command has finished executing, the hooks registered with snek.OnShutdown
+// are called, bounded by the timeout configured with snek.WithShutdownTimeout.
+// Any errors returned by the hooks are joined with the error returned by the
+// command.
 //
 // If the log level is set to `debug`, then a debug log line is written confirming
 // that debug logging is enabled.
-func Run(args []string, cfg *Config, initializers ...Initializer) error {
+func Run(args []string, cfg *Config, initializers ...Initializer) (err error) {
 	// ---------------------------------------------------------------------------
 	// Config
 	// ---------------------------------------------------------------------------
@@ -94,6 +186,23 @@ func Run(args []string, cfg *Config, initializers ...Initializer) error {
 		return err
 	}
 
+	if cfg.CompletionCommand {
+		completionCmd, err := newCompletionCommand(rootCmd)
+		if err != nil {
+			log.Error().Err(err).Msg("Error creating completion command")
+			return err
+		}
+
+		rootCmd.CompletionOptions.DisableDefaultCmd = true
+		rootCmd.AddCommand(completionCmd)
+	}
+
+	if cfg.HelpRenderer {
+		installHelpRenderer(rootCmd, cfg)
+	}
+
+	installFlagSuggestions(rootCmd)
+
 	// When the root command is runnable (has Run/RunE) and also has subcommands,
 	// cobra's legacyArgs validation in Find() rejects any positional arguments
 	// with an "unknown command" error. Auto-apply ArbitraryArgs when no custom
@@ -102,16 +211,31 @@ func Run(args []string, cfg *Config, initializers ...Initializer) error {
 		rootCmd.Args = cobra.ArbitraryArgs
 	}
 
+	if err := validateGroups(rootCmd); err != nil {
+		log.Error().Err(err).Msg("Error validating command groups")
+		return err
+	}
+
+	// ---------------------------------------------------------------------------
+	// Middleware
+	// ---------------------------------------------------------------------------
+
+	walkCommands(rootCmd, func(cmd *Command) {
+		applyMiddleware(cmd, cfg.Middleware)
+	})
+
 	// ---------------------------------------------------------------------------
 	// Logging
 	// ---------------------------------------------------------------------------
 
-	logFormat := getEnvOrDefault(
-		cfg.EnvironmentVariablePrefix+cfg.LogFormatEnvironmentVariableName,
-		cfg.DefaultLogFormat)
-	logLevel := getEnvOrDefault(
-		cfg.EnvironmentVariablePrefix+cfg.LogLevelEnvironmentVariableName,
-		cfg.DefaultLogLevel)
+	logFormatEnvVar := cfg.EnvironmentVariablePrefix + cfg.LogFormatEnvironmentVariableName
+	logLevelEnvVar := cfg.EnvironmentVariablePrefix + cfg.LogLevelEnvironmentVariableName
+	logFormat := getEnvOrDefault(logFormatEnvVar, cfg.DefaultLogFormat)
+	logLevel := getEnvOrDefault(logLevelEnvVar, cfg.DefaultLogLevel)
+	defaultLogLevel, _, err := parseLogLevels(cfg.DefaultLogLevel, "info")
+	if err != nil {
+		return err
+	}
 
 	pflags := rootCmd.PersistentFlags()
 	pflags.StringVarP(
@@ -127,6 +251,110 @@ func Run(args []string, cfg *Config, initializers ...Initializer) error {
 		logLevel,
 		cfg.LogLevelCommandLineVariableHelp)
 
+	if err := annotateEnvironmentVariable(pflags, cfg.LogFormatCommandLineVariableLongName, logFormatEnvVar); err != nil {
+		return err
+	}
+
+	if err := annotateEnvironmentVariable(pflags, cfg.LogLevelCommandLineVariableLongName, logLevelEnvVar); err != nil {
+		return err
+	}
+
+	err = annotateBuiltin(pflags, cfg.LogFormatCommandLineVariableLongName, cfg.LogLevelCommandLineVariableLongName)
+	if err != nil {
+		return err
+	}
+
+	logFileEnvVar := cfg.EnvironmentVariablePrefix + cfg.LogFileEnvironmentVariableName
+	logFile := getEnvOrDefault(logFileEnvVar, cfg.LogFile)
+	var openedLogFile io.WriteCloser
+	restoreSlog := func() {}
+	if cfg.LogFileFlag {
+		name := cfg.LogFileCommandLineVariableLongName
+		if rootCmd.Flags().Lookup(name) != nil || pflags.Lookup(name) != nil {
+			log.Error().Str("flag", name).Msg("Log file flag is already defined")
+			return fmt.Errorf("%w: --%s", ErrFlagDuplicate, name)
+		}
+
+		pflags.StringVar(&logFile, name, logFile, cfg.LogFileCommandLineVariableHelp)
+
+		if err := annotateEnvironmentVariable(pflags, name, logFileEnvVar); err != nil {
+			return err
+		}
+
+		if err := annotateBuiltin(pflags, name); err != nil {
+			return err
+		}
+	}
+
+	var logSampling string
+	if cfg.LogSamplingFlag {
+		logSamplingEnvVar := cfg.EnvironmentVariablePrefix + cfg.LogSamplingEnvironmentVariableName
+		err := WithStringVarE(&logSampling, cfg.LogSamplingCommandLineVariableLongName, logSamplingEnvVar, "",
+			cfg.LogSamplingCommandLineVariableHelp)(pflags)
+		if err == nil {
+			err = annotateBuiltin(pflags, cfg.LogSamplingCommandLineVariableLongName)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Error adding log sampling flag")
+			return err
+		}
+	}
+
+	// ---------------------------------------------------------------------------
+	// Output
+	// ---------------------------------------------------------------------------
+
+	if cfg.OutputFlag {
+		if err := addOutputFlags(rootCmd, cfg); err != nil {
+			log.Error().Err(err).Msg("Error adding output flags")
+			return err
+		}
+	}
+
+	// ---------------------------------------------------------------------------
+	// Prompts
+	// ---------------------------------------------------------------------------
+
+	var noInput bool
+	if cfg.Prompt {
+		err := WithBoolVarE(&noInput, "no-input", cfg.EnvironmentVariablePrefix+cfg.NoInputEnvironmentVariableName, false,
+			noInputFlagUsage)(pflags)
+		if err == nil {
+			err = annotateBuiltin(pflags, "no-input")
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Error adding no input flag")
+			return err
+		}
+	}
+
+	// ---------------------------------------------------------------------------
+	// Dry Run
+	// ---------------------------------------------------------------------------
+
+	var dryRun bool
+	if cfg.DryRunFlag {
+		err := WithBoolVarE(&dryRun, "dry-run", cfg.EnvironmentVariablePrefix+cfg.DryRunEnvironmentVariableName, false,
+			dryRunFlagUsage)(pflags)
+		if err == nil {
+			err = annotateBuiltin(pflags, "dry-run")
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Error adding dry run flag")
+			return err
+		}
+	}
+
+	// ---------------------------------------------------------------------------
+	// Confirmations
+	// ---------------------------------------------------------------------------
+
+	confirmationEnvVar := cfg.EnvironmentVariablePrefix + cfg.ConfirmationEnvironmentVariableName
+	if err := annotateConfirmations(rootCmd, confirmationEnvVar); err != nil {
+		log.Error().Err(err).Msg("Error annotating confirmation flags")
+		return err
+	}
+
 	// Use PersistentPreRunE instead of cobra.OnInitialize to scope logging setup
 	// to this command tree rather than the package-level global, which accumulates
 	// across multiple Run() calls (e.g. in tests). Chain any hooks the caller may
@@ -134,9 +362,68 @@ func Run(args []string, cfg *Config, initializers ...Initializer) error {
 	existingPreRunE := rootCmd.PersistentPreRunE
 	existingPreRun := rootCmd.PersistentPreRun
 	rootCmd.PersistentPreRunE = func(cmd *Command, args []string) error {
-		if err := setupLogging(logLevel, logFormat, cfg.LogOutput); err != nil {
+		logFields, logCaller, err := newLogFields(cfg, cmd)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			logFields["dry_run"] = true
+			cmd.SetContext(ContextWithDryRun(cmd.Context(), true))
+		}
+		logOutput, color := logOutputFor(cfg, cmd, cfg.LogOutput), true
+		if logFile != "" {
+			openedLogFile = NewLogFile(logFile, cfg.LogFileRotation)
+			logOutput, color = openedLogFile, false
+		}
+		level, components, err := parseLogLevels(logLevel, defaultLogLevel)
+		if err != nil {
 			return err
 		}
+		sampling := cfg.LogSampling
+		if logSampling != "" {
+			flagSampling, err := parseLogSampling(logSampling)
+			if err != nil {
+				return err
+			}
+			sampling = maps.Clone(sampling)
+			if sampling == nil {
+				sampling = map[string]LogSampling{}
+			}
+			maps.Copy(sampling, flagSampling)
+		}
+		sinks := newLogSinks(level, logFormat, logOutput, color, logSinksFor(cfg, cmd))
+		logger, lowest, err := newLogger(sinks, loggerOptions{
+			caller:        logCaller,
+			components:    components,
+			deduplication: cfg.LogDeduplication,
+			fields:        logFields,
+			formats:       cfg.LogFormats,
+			sampler:       newLogSampler(sampling),
+		})
+		if err != nil {
+			return err
+		}
+		if cfg.GlobalLogger {
+			log.Logger = *logger.Logger
+			zerolog.SetGlobalLevel(lowest)
+		}
+		setLogger(cmd, logger)
+		if cfg.SlogHandler {
+			restoreSlog = installSlogHandler(logger)
+		}
+		if cfg.EnvironmentVariableCheck {
+			checkEnvironmentVariables(logger.Logger, cfg.EnvironmentVariablePrefix,
+				knownEnvironmentVariables(cmd.Root(), slices.Concat(cfg.KnownEnvironmentVariables,
+					[]string{logFileEnvVar}, logFieldEnvironmentVariables(cfg))))
+		}
+		if cfg.Prompt {
+			if noInput {
+				disablePrompts(cmd)
+			}
+			if err := promptRequiredFlags(cmd, Prompter(cmd)); err != nil {
+				return err
+			}
+		}
 		if existingPreRunE != nil {
 			if err := existingPreRunE(cmd, args); err != nil {
 				return err
@@ -152,9 +439,41 @@ func Run(args []string, cfg *Config, initializers ...Initializer) error {
 	// Execute
 	// ---------------------------------------------------------------------------
 
+	ctx := context.Background()
+	if len(cfg.ShutdownSignals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, cfg.ShutdownSignals...)
+		defer stop()
+
+		// Restore the default signal behavior once the first signal has been
+		// received so a second signal terminates a command that does not
+		// respect its context.
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+	}
+
+	// Run the shutdown hooks in a deferred function so they are also called
+	// when the command panics.
+	defer func() {
+		defer restoreSlog()
+
+		if shutdownErr := shutdown(rootCmd, cfg.ShutdownTimeout); shutdownErr != nil {
+			err = errors.Join(err, shutdownErr)
+		}
+
+		// Write the summaries of the deduplicated logs and close the log file
+		// once the shutdown hooks, which may log, have run.
+		Logger(rootCmd).flush()
+		if openedLogFile != nil {
+			err = errors.Join(err, openedLogFile.Close())
+		}
+	}()
+
 	rootCmd.SetArgs(args)
-	if err := rootCmd.Execute(); err != nil {
-		log.Error().Err(err).Msg("Error executing command")
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		Logger(rootCmd).Error().Err(err).Msg("Error executing command")
 		return err
 	}
 
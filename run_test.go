@@ -8,7 +8,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -115,6 +114,28 @@ func TestRun_Config_LogLevelHelp(t *testing.T) {
 	require.True(t, called, "Run should call the commands Run function")
 }
 
+func TestRun_Config_EnvironmentVariableAnnotations(t *testing.T) {
+	cfg := snek.NewConfig(
+		snek.WithEnvironmentVariablePrefix("TEST_"),
+		snek.WithLogOutput(io.Discard),
+	)
+
+	called := false
+	err := snek.Run(nil, cfg,
+		snek.WithRun(func(cmd *cobra.Command, args []string) {
+			called = true
+			assert.Equal(t, []string{"TEST_LOG_FORMAT"},
+				cmd.Flag(cfg.LogFormatCommandLineVariableLongName).Annotations[snek.FlagAnnotationEnvironmentVariable],
+				"Run should annotate the log format flag with its prefixed environment variable")
+			assert.Equal(t, []string{"TEST_LOG_LEVEL"},
+				cmd.Flag(cfg.LogLevelCommandLineVariableLongName).Annotations[snek.FlagAnnotationEnvironmentVariable],
+				"Run should annotate the log level flag with its prefixed environment variable")
+		}),
+	)
+	assert.NoError(t, err, "Run should not return an error")
+	require.True(t, called, "Run should call the commands Run function")
+}
+
 func TestRun_Execute_Error(t *testing.T) {
 	err := snek.Run(nil, snek.NewConfig(),
 		snek.WithRunE(func(cmd *cobra.Command, args []string) error {
@@ -241,7 +262,7 @@ func TestRun_Logging_Format_Invalid(t *testing.T) {
 	err := snek.Run(nil, cfg,
 		snek.WithRun(func(cmd *cobra.Command, args []string) {
 			called = true
-			log.Debug().Msg("debug test")
+			snek.Logger(cmd).Debug().Msg("debug test")
 		}),
 	)
 	assert.ErrorIs(t, err, snek.ErrLogFormatInvalid, "Run should return an error")
@@ -328,7 +349,7 @@ func TestRun_Logging_Level_Invalid(t *testing.T) {
 	err := snek.Run(nil, cfg,
 		snek.WithRun(func(cmd *cobra.Command, args []string) {
 			called = true
-			log.Debug().Msg("debug test")
+			snek.Logger(cmd).Debug().Msg("debug test")
 		}),
 	)
 	assert.ErrorIs(t, err, snek.ErrLogLevelInvalid, "Run should return an error")
@@ -348,10 +369,10 @@ func runLogLinesTestWithConfig(t *testing.T, cfg *snek.Config, expectedLines int
 
 	err := snek.Run(args, cfg,
 		snek.WithRun(func(cmd *cobra.Command, args []string) {
-			log.Debug().Msg("debug test")
-			log.Info().Msg("info test")
-			log.Warn().Msg("warn test")
-			log.Error().Msg("error test")
+			snek.Logger(cmd).Debug().Msg("debug test")
+			snek.Logger(cmd).Info().Msg("info test")
+			snek.Logger(cmd).Warn().Msg("warn test")
+			snek.Logger(cmd).Error().Msg("error test")
 		}),
 	)
 	require.NoError(t, err, "Run should not return an error")
@@ -378,6 +399,12 @@ func runLogLinesTest(t *testing.T, format, level string, expectedLines int, args
 func runLogLinesJsonTestWithConfig(t *testing.T, cfg *snek.Config, expectedLines int, args []string) []parsedLogLine {
 	t.Helper()
 	lines := runLogLinesTestWithConfig(t, cfg, expectedLines, args)
+	return parseLogLines(t, strings.Join(lines, "\n"))
+}
+
+func parseLogLines(t *testing.T, output string) []parsedLogLine {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
 
 	parsedLines := make([]parsedLogLine, len(lines))
 	for i, line := range lines {
@@ -473,3 +500,30 @@ func testEnvironmentVariable(t *testing.T, name, value string) func() {
 		}
 	}
 }
+
+func TestRun_ReusedCommand(t *testing.T) {
+	type dependency struct{}
+
+	sub, err := snek.NewCommand(
+		snek.WithUse("sub"),
+		snek.WithProvider(func(*cobra.Command) (*dependency, error) { return &dependency{}, nil }),
+		snek.WithRunE(func(cmd *cobra.Command, _ []string) error {
+			_, err := snek.Get[*dependency](cmd)
+			return err
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	for range 2 {
+		var help bytes.Buffer
+		err = snek.Run([]string{"sub"}, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+			snek.WithUse("app"),
+			snek.WithSubCommand(sub),
+		)
+		require.NoError(t, err, "Run should not return an error when a command is reused")
+
+		sub.SetOut(&help)
+		require.NoError(t, sub.Help(), "Help should not return an error")
+		assert.NotContains(t, help.String(), "extensions", "The state of the command should not be listed as a flag")
+	}
+}
@@ -0,0 +1,87 @@
+package snek
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ShutdownHook is a function that releases resources acquired by a command. The
+// context passed to the hook is cancelled once the configured shutdown timeout
+// has elapsed, and carries the logger of the command returned by zerolog.Ctx.
+type ShutdownHook func(ctx context.Context) error
+
+// OnShutdown registers the hook to be called once the command tree containing
+// the specified command has finished executing. Hooks are called by Run in the
+// reverse order they were registered, whether the command succeeded, returned
+// an error, panicked, or was interrupted by a shutdown signal.
+//
+// Hooks are registered on the root of the command tree, so OnShutdown should
+// be called once the command has been added to its parent, such as from within
+// a run function.
+func OnShutdown(cmd *Command, hook ShutdownHook) {
+	ext := extensionsFor(cmd.Root())
+	ext.mu.Lock()
+	defer ext.mu.Unlock()
+	ext.shutdownHooks = append(ext.shutdownHooks, hook)
+}
+
+// shutdown calls the shutdown hooks registered on the specified root command
+// in the reverse order they were registered and then clears them. If timeout
+// is greater than zero, then any hooks that have not been called once the
+// timeout has elapsed are skipped and an error wrapping ErrShutdownTimeout is
+// returned.
+//
+// Each error returned by a hook is logged, and all errors are joined and
+// returned.
+func shutdown(root *Command, timeout time.Duration) error {
+	ext := lookupExtensions(root)
+	if ext == nil {
+		return nil
+	}
+
+	ext.mu.Lock()
+	hooks := ext.shutdownHooks
+	ext.shutdownHooks = nil
+	ext.mu.Unlock()
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	logger := Logger(root)
+	ctx := logger.WithContext(context.Background())
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		result := make(chan error, 1)
+		go func(hook ShutdownHook) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					result <- fmt.Errorf("%w: %v", ErrShutdownHookPanicked, recovered)
+				}
+			}()
+			result <- hook(ctx)
+		}(hooks[i])
+
+		select {
+		case err := <-result:
+			if err != nil {
+				logger.Error().Err(err).Msg("Error running shutdown hook")
+				errs = append(errs, err)
+			}
+		case <-ctx.Done():
+			err := fmt.Errorf("%w: %d hook(s) did not complete within %s", ErrShutdownTimeout, i+1, timeout)
+			logger.Error().Err(err).Msg("Error running shutdown hooks")
+			return errors.Join(append(errs, err)...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
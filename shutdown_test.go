@@ -0,0 +1,130 @@
+package snek_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func TestOnShutdown_Order(t *testing.T) {
+	var calls []string
+	err := snek.Run(nil, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithRun(func(cmd *cobra.Command, args []string) {
+			for _, name := range []string{"first", "second", "third"} {
+				snek.OnShutdown(cmd, func(context.Context) error {
+					calls = append(calls, name)
+					return nil
+				})
+			}
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+	assert.Equal(t, []string{"third", "second", "first"}, calls,
+		"Shutdown hooks should be called in the reverse order they were registered")
+}
+
+func TestOnShutdown_SubCommand(t *testing.T) {
+	called := false
+	subCmd, err := snek.NewCommand(
+		snek.WithUse("sub"),
+		snek.WithRun(func(cmd *cobra.Command, args []string) {
+			snek.OnShutdown(cmd, func(context.Context) error {
+				called = true
+				return nil
+			})
+		}),
+	)
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	err = snek.Run([]string{"sub"}, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithUse("root"),
+		snek.WithSubCommand(subCmd),
+	)
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, called, "Shutdown hooks registered by subcommands should be called")
+}
+
+func TestOnShutdown_CommandError(t *testing.T) {
+	called := false
+	err := snek.Run(nil, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+			snek.OnShutdown(cmd, func(context.Context) error {
+				called = true
+				return nil
+			})
+			return assert.AnError
+		}),
+	)
+	assert.ErrorIs(t, err, assert.AnError, "Run should return the command error")
+	assert.True(t, called, "Shutdown hooks should be called when the command returns an error")
+}
+
+func TestOnShutdown_CommandPanic(t *testing.T) {
+	called := false
+	assert.Panics(t, func() {
+		_ = snek.Run(nil, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+			snek.WithRun(func(cmd *cobra.Command, args []string) {
+				snek.OnShutdown(cmd, func(context.Context) error {
+					called = true
+					return nil
+				})
+				panic("boom")
+			}),
+		)
+	}, "Run should not swallow the panic")
+	assert.True(t, called, "Shutdown hooks should be called when the command panics")
+}
+
+func TestOnShutdown_Errors(t *testing.T) {
+	first := errors.New("first")
+	second := errors.New("second")
+	err := snek.Run(nil, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+			snek.OnShutdown(cmd, func(context.Context) error { return first })
+			snek.OnShutdown(cmd, func(context.Context) error { panic("boom") })
+			snek.OnShutdown(cmd, func(context.Context) error { return second })
+			return assert.AnError
+		}),
+	)
+	assert.ErrorIs(t, err, assert.AnError, "Run should return the command error")
+	assert.ErrorIs(t, err, first, "Run should return the first hook error")
+	assert.ErrorIs(t, err, second, "Run should return the second hook error")
+	assert.ErrorIs(t, err, snek.ErrShutdownHookPanicked, "Run should return the hook panic as an error")
+}
+
+func TestOnShutdown_Timeout(t *testing.T) {
+	called := false
+	err := snek.Run(nil,
+		snek.NewConfig(
+			snek.WithLogOutput(io.Discard),
+			snek.WithShutdownTimeout(10*time.Millisecond),
+		),
+		snek.WithRun(func(cmd *cobra.Command, args []string) {
+			snek.OnShutdown(cmd, func(context.Context) error {
+				called = true
+				return nil
+			})
+			snek.OnShutdown(cmd, func(context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			})
+		}),
+	)
+	assert.ErrorIs(t, err, snek.ErrShutdownTimeout, "Run should return a shutdown timeout error")
+	assert.False(t, called, "Hooks remaining after the timeout should not be called")
+}
+
+func TestRun_Config_InvalidShutdownTimeout(t *testing.T) {
+	cfg := snek.NewConfig(snek.WithShutdownTimeout(-time.Second))
+	err := snek.Run(nil, cfg)
+	assert.ErrorIs(t, err, snek.ErrShutdownTimeoutInvalid,
+		"Run should return an error if the shutdown timeout is negative")
+}
@@ -0,0 +1,202 @@
+package snek
+
+import (
+	"context"
+	stdlog "log"
+	"log/slog"
+	"runtime"
+	"slices"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// slogHandler is a slog.Handler that writes records to a zerolog logger.
+type slogHandler struct {
+	// logger is the logger records are written to, or nil to write them to the
+	// global logger.
+	logger *zerolog.Logger
+
+	// attrs are the attributes added with WithAttrs.
+	attrs []prefixedSlogAttr
+
+	// prefix is the group names added with WithGroup, joined with periods and
+	// followed by a period.
+	prefix string
+
+	// caller is true when the caller of each record, resolved from its program
+	// counter, is added to its log line.
+	caller bool
+}
+
+// prefixedSlogAttr is an attribute added with WithAttrs, along with the prefix
+// of the groups it was added in.
+type prefixedSlogAttr struct {
+	prefix string
+	attr   slog.Attr
+}
+
+// NewSlogHandler creates a slog.Handler that writes records to the logger, so
+// libraries that log with log/slog honor the level, format, output and fields
+// of the logger. If the logger is nil, then records are written to the global
+// zerolog logger set up by Run at the time they are logged. Use Slog for a
+// log/slog logger that writes to the logger of a command executed by Run,
+// which also adds the callers of the records when the caller is logged.
+//
+// Attributes are written as fields of the log line. The keys of attributes in
+// groups are prefixed with the group names, separated by periods, such as
+// `http.method`. The slog levels are mapped to the closest zerolog levels.
+func NewSlogHandler(logger *zerolog.Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+// Enabled returns true if a record at the level would be written by the logger.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	zerologLevel := zerologLevel(level)
+	return zerologLevel >= zerolog.GlobalLevel() && zerologLevel >= h.zerologLogger().GetLevel()
+}
+
+// Handle writes the record to the logger.
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	logger := h.zerologLogger()
+	event := logger.WithLevel(zerologLevel(record.Level)).Ctx(ctx)
+	for _, attr := range h.attrs {
+		event = addSlogAttr(event, attr.prefix, attr.attr)
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		event = addSlogAttr(event, h.prefix, attr)
+		return true
+	})
+
+	if h.caller && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		event = event.Str(zerolog.CallerFieldName, zerolog.CallerMarshalFunc(frame.PC, frame.File, frame.Line))
+	}
+
+	event.Msg(record.Message)
+	return nil
+}
+
+// WithAttrs returns a handler that adds the attributes to every record.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = slices.Clip(h.attrs)
+	for _, attr := range attrs {
+		handler.attrs = append(handler.attrs, prefixedSlogAttr{prefix: h.prefix, attr: attr})
+	}
+	return &handler
+}
+
+// WithGroup returns a handler that prefixes the keys of the attributes added
+// to it afterwards with the group name.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	handler := *h
+	handler.prefix = h.prefix + name + "."
+	return &handler
+}
+
+// zerologLogger returns the logger records are written to.
+func (h *slogHandler) zerologLogger() *zerolog.Logger {
+	if h.logger != nil {
+		return h.logger
+	}
+	return &log.Logger
+}
+
+// zerologLevel returns the zerolog level closest to the slog level. Levels
+// below debug are mapped to trace, and levels above error to error.
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case level < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case level < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case level < slog.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+// addSlogAttr adds the attribute to the event as a field, prefixing its key
+// with the prefix. Groups are flattened, with the keys of their attributes
+// prefixed with the group name.
+func addSlogAttr(event *zerolog.Event, prefix string, attr slog.Attr) *zerolog.Event {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return event
+	}
+
+	value := attr.Value
+	key := prefix + attr.Key
+	switch value.Kind() {
+	case slog.KindGroup:
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = key + "."
+		}
+		for _, groupAttr := range value.Group() {
+			event = addSlogAttr(event, groupPrefix, groupAttr)
+		}
+		return event
+	case slog.KindString:
+		return event.Str(key, value.String())
+	case slog.KindInt64:
+		return event.Int64(key, value.Int64())
+	case slog.KindUint64:
+		return event.Uint64(key, value.Uint64())
+	case slog.KindFloat64:
+		return event.Float64(key, value.Float64())
+	case slog.KindBool:
+		return event.Bool(key, value.Bool())
+	case slog.KindDuration:
+		return event.Dur(key, value.Duration())
+	case slog.KindTime:
+		return event.Time(key, value.Time())
+	default:
+		if err, ok := value.Any().(error); ok {
+			return event.AnErr(key, err)
+		}
+		return event.Interface(key, value.Any())
+	}
+}
+
+// Slog returns a log/slog logger that writes to the logger of the command
+// returned by Logger, so it can be passed to libraries that log with log/slog
+// without changing the default log/slog logger. When the caller is logged,
+// the caller of each record is the code that logged it rather than the
+// handler.
+func Slog(cmd *Command) *slog.Logger {
+	return slog.New(Logger(cmd).slogHandler())
+}
+
+// slogHandler returns a slog.Handler that writes records to the logger, with
+// the caller of each record when the caller is logged.
+func (l *ScopedLogger) slogHandler() slog.Handler {
+	if l.uncalled == nil {
+		return NewSlogHandler(l.Logger)
+	}
+	return &slogHandler{logger: l.uncalled, caller: l.caller}
+}
+
+// installSlogHandler sets the default slog logger to one that writes to the
+// logger, and returns a function that restores the previous default logger and
+// the output of the standard log package, which slog.SetDefault redirects.
+func installSlogHandler(logger *ScopedLogger) func() {
+	previous := slog.Default()
+	writer, flags := stdlog.Writer(), stdlog.Flags()
+	slog.SetDefault(slog.New(logger.slogHandler()))
+
+	return func() {
+		slog.SetDefault(previous)
+		stdlog.SetOutput(writer)
+		stdlog.SetFlags(flags)
+	}
+}
@@ -0,0 +1,206 @@
+package snek_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+// allowAllLogLevels sets the global zerolog level to trace for the duration of
+// the test, since earlier calls to Run may have raised it.
+func allowAllLogLevels(t *testing.T) {
+	t.Helper()
+	previous := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	t.Cleanup(func() { zerolog.SetGlobalLevel(previous) })
+}
+
+// decodeLogLines decodes every JSON log line written to the buffer.
+func decodeLogLines(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), "The log line should be JSON")
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestNewSlogHandler(t *testing.T) {
+	allowAllLogLevels(t)
+	var logs bytes.Buffer
+	zerologger := zerolog.New(&logs).Level(zerolog.DebugLevel)
+	logger := slog.New(snek.NewSlogHandler(&zerologger))
+
+	logger.Debug("debug test")
+	logger.Log(context.Background(), slog.LevelDebug-4, "trace test")
+	logger.
+		With("service", "api").
+		WithGroup("http").
+		With("method", "GET").
+		Warn("request failed",
+			"status", 503,
+			"duration", time.Second,
+			"retry", true,
+			"err", errors.New("unavailable"),
+			slog.Group("client", "ip", "10.0.0.1"),
+			slog.Group("", "inlined", 1.5),
+		)
+
+	entries := decodeLogLines(t, &logs)
+	require.Len(t, entries, 2, "Records below the level of the logger should not be written")
+	assert.Equal(t, map[string]any{"level": "debug", "message": "debug test"}, entries[0],
+		"The debug record should be written")
+	assert.Equal(t, map[string]any{
+		"level":          "warn",
+		"message":        "request failed",
+		"service":        "api",
+		"http.method":    "GET",
+		"http.status":    float64(503),
+		"http.duration":  float64(1000),
+		"http.retry":     true,
+		"http.err":       "unavailable",
+		"http.client.ip": "10.0.0.1",
+		"http.inlined":   1.5,
+	}, entries[1], "The attributes should be written as fields")
+}
+
+func TestNewSlogHandler_Levels(t *testing.T) {
+	allowAllLogLevels(t)
+	tests := []struct {
+		level    slog.Level
+		expected string
+	}{
+		{level: slog.LevelDebug - 1, expected: "trace"},
+		{level: slog.LevelDebug, expected: "debug"},
+		{level: slog.LevelInfo, expected: "info"},
+		{level: slog.LevelWarn, expected: "warn"},
+		{level: slog.LevelError, expected: "error"},
+		{level: slog.LevelError + 4, expected: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			var logs bytes.Buffer
+			zerologger := zerolog.New(&logs)
+			slog.New(snek.NewSlogHandler(&zerologger)).Log(context.Background(), tt.level, "test")
+			entries := decodeLogLines(t, &logs)
+			require.Len(t, entries, 1, "The record should be written")
+			assert.Equal(t, tt.expected, entries[0]["level"], "The level should be mapped to the closest zerolog level")
+		})
+	}
+
+	zerologger := zerolog.New(&bytes.Buffer{}).Level(zerolog.WarnLevel)
+	handler := snek.NewSlogHandler(&zerologger)
+	assert.False(t, handler.Enabled(context.Background(), slog.LevelInfo), "Info should not be enabled at the warn level")
+	assert.True(t, handler.Enabled(context.Background(), slog.LevelError), "Error should be enabled at the warn level")
+}
+
+func TestWithSlogHandler(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.SlogHandler, "SlogHandler should be false")
+	cfg = snek.NewConfig(snek.WithSlogHandler(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.SlogHandler, "SlogHandler should be true")
+}
+
+func TestRun_SlogHandler(t *testing.T) {
+	previous := slog.Default()
+	var logs bytes.Buffer
+	cfg := snek.NewConfig(
+		snek.WithSlogHandler(true),
+		snek.WithDryRunFlag(true),
+		snek.WithDefaultLogFormat("json"),
+		snek.WithLogOutput(&logs),
+	)
+	err := snek.Run([]string{"--log-level", "warn", "--dry-run"}, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(*cobra.Command, []string) {
+			slog.Info("info test")
+			slog.Warn("warn test", "table", "users")
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	entries := decodeLogLines(t, &logs)
+	require.Len(t, entries, 1, "Records below the log level should not be written")
+	assert.Equal(t, "warn test", entries[0]["message"], "The record should be written to the log output")
+	assert.Equal(t, "users", entries[0]["table"], "The attributes should be written")
+	assert.Equal(t, true, entries[0]["dry_run"], "The fields set up by Run should be written")
+	assert.Same(t, previous, slog.Default(), "The default slog logger should be restored")
+}
+
+func TestRun_SlogHandler_Disabled(t *testing.T) {
+	previous := slog.Default()
+	err := snek.Run(nil, snek.NewConfig(snek.WithLogOutput(&bytes.Buffer{})),
+		snek.WithUse("app"),
+		snek.WithRun(func(*cobra.Command, []string) {
+			assert.Same(t, previous, slog.Default(), "The default slog logger should not be changed")
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+}
+
+func TestRun_SlogHandler_Caller(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := snek.NewConfig(
+		snek.WithSlogHandler(true),
+		snek.WithLogCaller(true),
+		snek.WithDefaultLogFormat("json"),
+		snek.WithLogOutput(&logs),
+	)
+	err := snek.Run([]string{"--log-level", "warn"}, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(*cobra.Command, []string) {
+			slog.Warn("caller test")
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	assert.Equal(t, 1, strings.Count(logs.String(), `"caller":`), "The caller should be written once")
+	entries := decodeLogLines(t, &logs)
+	require.Len(t, entries, 1, "The record should be written")
+	assert.Contains(t, entries[0]["caller"], "slog_test.go:", "The caller should be the code that logged the record")
+}
+
+func TestSlog(t *testing.T) {
+	previous := slog.Default()
+	var logs bytes.Buffer
+	cfg := snek.NewConfig(
+		snek.WithLogCaller(true),
+		snek.WithDefaultLogFormat("json"),
+		snek.WithLogOutput(&logs),
+	)
+	err := snek.Run([]string{"--log-level", "warn"}, cfg,
+		snek.WithUse("app"),
+		snek.WithRun(func(cmd *cobra.Command, _ []string) {
+			assert.Same(t, previous, slog.Default(), "Slog should not change the default slog logger")
+			snek.Slog(cmd).Info("info test")
+			snek.Slog(cmd).Warn("warn test", "table", "users")
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+
+	entries := decodeLogLines(t, &logs)
+	require.Len(t, entries, 1, "Records below the log level should not be written")
+	assert.Equal(t, "warn test", entries[0]["message"], "The record should be written to the log output")
+	assert.Equal(t, "users", entries[0]["table"], "The attributes should be written")
+	assert.Contains(t, entries[0]["caller"], "slog_test.go:", "The caller should be the code that logged the record")
+}
@@ -0,0 +1,324 @@
+package snek
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommandSpec is the declarative specification of a command and its
+// subcommands. Specifications are written in YAML or JSON, and are turned into
+// commands by WithSpec.
+type CommandSpec struct {
+	// Use is the one line usage message of the command.
+	Use string `yaml:"use" json:"use"`
+
+	// Aliases are the aliases of the command.
+	Aliases []string `yaml:"aliases" json:"aliases"`
+
+	// Short is the short description of the command.
+	Short string `yaml:"short" json:"short"`
+
+	// Long is the long description of the command.
+	Long string `yaml:"long" json:"long"`
+
+	// Example is the example usage of the command.
+	Example string `yaml:"example" json:"example"`
+
+	// Deprecated is the deprecation message of the command.
+	Deprecated string `yaml:"deprecated" json:"deprecated"`
+
+	// Version is the version of the command.
+	Version string `yaml:"version" json:"version"`
+
+	// ValidArgs are the valid positional arguments of the command.
+	ValidArgs []string `yaml:"valid_args" json:"valid_args"`
+
+	// Handler is the name of the handler that implements the command. The name
+	// must be registered in the handlers passed to WithSpec.
+	Handler string `yaml:"handler" json:"handler"`
+
+	// Group is the ID of the group of the parent the command is listed under.
+	Group string `yaml:"group" json:"group"`
+
+	// Groups are the groups the subcommands of the command are listed under.
+	Groups []GroupSpec `yaml:"groups" json:"groups"`
+
+	// Flags are the flags of the command.
+	Flags []FlagSpec `yaml:"flags" json:"flags"`
+
+	// Commands are the subcommands of the command.
+	Commands []CommandSpec `yaml:"commands" json:"commands"`
+}
+
+// GroupSpec is the declarative specification of a command group.
+type GroupSpec struct {
+	// ID is the ID of the group.
+	ID string `yaml:"id" json:"id"`
+
+	// Title is the title of the group displayed in the help output.
+	Title string `yaml:"title" json:"title"`
+}
+
+// FlagSpec is the declarative specification of a flag.
+type FlagSpec struct {
+	// Name is the long name of the flag.
+	Name string `yaml:"name" json:"name"`
+
+	// Shorthand is the short name of the flag.
+	Shorthand string `yaml:"shorthand" json:"shorthand"`
+
+	// Type is the type of the flag.
+	//
+	// Valid values are `bool`, `duration`, `float32`, `float64`, `int`, `int8`,
+	// `int16`, `int32`, `int64`, `string`, `uint`, `uint8`, `uint16`, `uint32`,
+	// and `uint64`.
+	//
+	// The default value is `string`.
+	Type string `yaml:"type" json:"type"`
+
+	// Default is the default value of the flag, parsed as the type of the flag.
+	Default string `yaml:"default" json:"default"`
+
+	// Usage is the help text of the flag.
+	Usage string `yaml:"usage" json:"usage"`
+
+	// Env is the name of the environment variable that overrides the default
+	// value of the flag.
+	Env string `yaml:"env" json:"env"`
+
+	// Persistent is true when the flag is also available to the descendants of
+	// the command.
+	Persistent bool `yaml:"persistent" json:"persistent"`
+
+	// Required is true when the flag must be set.
+	Required bool `yaml:"required" json:"required"`
+}
+
+// LoadSpec reads the command specification from the YAML or JSON file at the
+// specified path.
+func LoadSpec(path string) (*CommandSpec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseSpec(file)
+}
+
+// ParseSpec reads the command specification from the specified YAML or JSON
+// reader. Unknown fields are rejected so typos in the specification are not
+// silently ignored. If the specification cannot be parsed, then an error
+// wrapping ErrSpecInvalid is returned.
+func ParseSpec(r io.Reader) (*CommandSpec, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	var spec CommandSpec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSpecInvalid, err)
+	}
+
+	return &spec, nil
+}
+
+// WithSpec initializes the command, and creates its subcommands, from the
+// specified command specification. Each command is created through the
+// existing initializers, and the initializer registered in handlers under the
+// name of the Handler of the command specification is applied last. Handlers
+// are usually created with WithRunE or WithHandler.
+//
+// Before the command is initialized, the specification is validated. If a
+// handler referenced by the specification is not registered, then an error
+// wrapping ErrSpecHandlerNotFound is returned for each missing handler. If a
+// flag has an invalid type or default, then an error wrapping ErrSpecInvalid
+// is returned.
+func WithSpec(spec *CommandSpec, handlers map[string]Initializer) Initializer {
+	return func(cmd *Command) error {
+		if err := validateSpecHandlers(spec, handlers); err != nil {
+			return err
+		}
+
+		initializers, err := specInitializers(spec, handlers)
+		if err != nil {
+			return err
+		}
+
+		for _, initializer := range initializers {
+			if err := initializer(cmd); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// validateSpecHandlers returns an error wrapping ErrSpecHandlerNotFound for
+// each handler referenced by the specification, or its descendants, that is
+// not registered.
+func validateSpecHandlers(spec *CommandSpec, handlers map[string]Initializer) error {
+	var errs []error
+	if spec.Handler != "" {
+		if _, ok := handlers[spec.Handler]; !ok {
+			errs = append(errs, fmt.Errorf("%w: %q referenced by command %q",
+				ErrSpecHandlerNotFound, spec.Handler, spec.Use))
+		}
+	}
+
+	for i := range spec.Commands {
+		errs = append(errs, validateSpecHandlers(&spec.Commands[i], handlers))
+	}
+
+	return errors.Join(errs...)
+}
+
+// specInitializers returns the initializers that create the command described
+// by the specification.
+func specInitializers(spec *CommandSpec, handlers map[string]Initializer) ([]Initializer, error) {
+	initializers := []Initializer{
+		WithUse(spec.Use),
+		WithShort(spec.Short),
+		WithLong(spec.Long),
+		WithExample(spec.Example),
+		WithDeprecated(spec.Deprecated),
+		WithVersion(spec.Version),
+		WithGroupID(spec.Group),
+	}
+
+	if len(spec.Aliases) > 0 {
+		initializers = append(initializers, WithAliases(spec.Aliases...))
+	}
+
+	if len(spec.ValidArgs) > 0 {
+		initializers = append(initializers, WithValidArgs(spec.ValidArgs...))
+	}
+
+	for _, group := range spec.Groups {
+		initializers = append(initializers, WithGroup(group.ID, group.Title))
+	}
+
+	var required []string
+	for i := range spec.Flags {
+		flag := &spec.Flags[i]
+		flagInitializer, err := specFlagInitializer(flag)
+		if err != nil {
+			return nil, fmt.Errorf("%w: flag %q of command %q: %v", ErrSpecInvalid, flag.Name, spec.Use, err)
+		}
+
+		if flag.Persistent {
+			initializers = append(initializers, WithPersistentFlag(flagInitializer))
+		} else {
+			initializers = append(initializers, WithFlag(flagInitializer))
+		}
+
+		if flag.Required {
+			required = append(required, flag.Name)
+		}
+	}
+
+	if len(required) > 0 {
+		initializers = append(initializers, WithRequiredFlag(required...))
+	}
+
+	for i := range spec.Commands {
+		child, err := specInitializers(&spec.Commands[i], handlers)
+		if err != nil {
+			return nil, err
+		}
+
+		initializers = append(initializers, WithSubCommandGenerator(func() (*Command, error) {
+			return NewCommand(child...)
+		}))
+	}
+
+	if spec.Handler != "" {
+		initializers = append(initializers, handlers[spec.Handler])
+	}
+
+	return initializers, nil
+}
+
+// specFlagInitializer returns the flag initializer that creates the flag
+// described by the specification.
+func specFlagInitializer(spec *FlagSpec) (FlagInitializer, error) {
+	switch spec.Type {
+	case "bool":
+		return specFlag(spec, strconv.ParseBool, WithBoolVarPE)
+	case "duration":
+		return specFlag(spec, time.ParseDuration, WithDurationVarPE)
+	case "float32":
+		return specFlag(spec, parseSpecFloat[float32], WithFloat32VarPE)
+	case "float64":
+		return specFlag(spec, parseSpecFloat[float64], WithFloat64VarPE)
+	case "int":
+		return specFlag(spec, strconv.Atoi, WithIntVarPE)
+	case "int8":
+		return specFlag(spec, parseSpecInt[int8], WithInt8VarPE)
+	case "int16":
+		return specFlag(spec, parseSpecInt[int16], WithInt16VarPE)
+	case "int32":
+		return specFlag(spec, parseSpecInt[int32], WithInt32VarPE)
+	case "int64":
+		return specFlag(spec, parseSpecInt[int64], WithInt64VarPE)
+	case "", "string":
+		return specFlag(spec, func(value string) (string, error) { return value, nil }, WithStringVarPE)
+	case "uint":
+		return specFlag(spec, parseSpecUint[uint], WithUintVarPE)
+	case "uint8":
+		return specFlag(spec, parseSpecUint[uint8], WithUint8VarPE)
+	case "uint16":
+		return specFlag(spec, parseSpecUint[uint16], WithUint16VarPE)
+	case "uint32":
+		return specFlag(spec, parseSpecUint[uint32], WithUint32VarPE)
+	case "uint64":
+		return specFlag(spec, parseSpecUint[uint64], WithUint64VarPE)
+	default:
+		return nil, fmt.Errorf("unknown type %q", spec.Type)
+	}
+}
+
+// specFlag parses the default value of the flag specification and returns the
+// flag initializer that creates the flag. The value of the flag is stored in a
+// newly allocated variable, and is read through the flags of the command.
+func specFlag[T any](
+	spec *FlagSpec,
+	parse func(string) (T, error),
+	initializer func(variable *T, name, shorthand, envVar string, value T, usage string) FlagInitializer,
+) (FlagInitializer, error) {
+	var value T
+	if spec.Default != "" {
+		parsed, err := parse(spec.Default)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default %q: %v", spec.Default, err)
+		}
+		value = parsed
+	}
+
+	return initializer(new(T), spec.Name, spec.Shorthand, spec.Env, value, spec.Usage), nil
+}
+
+// parseSpecFloat parses the value as a float of the size of T.
+func parseSpecFloat[T float32 | float64](value string) (T, error) {
+	parsed, err := strconv.ParseFloat(value, reflect.TypeFor[T]().Bits())
+	return T(parsed), err
+}
+
+// parseSpecInt parses the value as a signed integer of the size of T.
+func parseSpecInt[T int8 | int16 | int32 | int64](value string) (T, error) {
+	parsed, err := strconv.ParseInt(value, 10, reflect.TypeFor[T]().Bits())
+	return T(parsed), err
+}
+
+// parseSpecUint parses the value as an unsigned integer of the size of T.
+func parseSpecUint[T uint | uint8 | uint16 | uint32 | uint64](value string) (T, error) {
+	parsed, err := strconv.ParseUint(value, 10, reflect.TypeFor[T]().Bits())
+	return T(parsed), err
+}
@@ -0,0 +1,148 @@
+package snek_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+const testSpecYAML = `
+use: app
+short: The app
+groups:
+  - id: core
+    title: "Core Commands:"
+flags:
+  - name: verbose
+    shorthand: v
+    type: bool
+    persistent: true
+    usage: Enable verbose output
+commands:
+  - use: serve
+    short: Serve the app
+    group: core
+    aliases: [server]
+    handler: serve
+    flags:
+      - name: port
+        shorthand: p
+        type: int
+        default: 8080
+        env: TEST_SPEC_PORT
+        usage: The port to bind to
+      - name: host
+        required: true
+  - use: version
+    short: Print the version
+    handler: version
+`
+
+func parseTestSpec(t *testing.T, spec string) *snek.CommandSpec {
+	t.Helper()
+	parsed, err := snek.ParseSpec(strings.NewReader(spec))
+	require.NoError(t, err, "ParseSpec should not return an error")
+	return parsed
+}
+
+func TestParseSpec_JSON(t *testing.T) {
+	spec := parseTestSpec(t, `{"use": "app", "commands": [{"use": "serve", "flags": [{"name": "port", "type": "int"}]}]}`)
+	assert.Equal(t, "app", spec.Use, "Use should be parsed")
+	require.Len(t, spec.Commands, 1, "Commands should be parsed")
+	require.Len(t, spec.Commands[0].Flags, 1, "Flags should be parsed")
+	assert.Equal(t, "int", spec.Commands[0].Flags[0].Type, "The flag type should be parsed")
+}
+
+func TestParseSpec_UnknownField(t *testing.T) {
+	_, err := snek.ParseSpec(strings.NewReader("use: app\nshrot: typo\n"))
+	assert.ErrorIs(t, err, snek.ErrSpecInvalid, "ParseSpec should return an error for unknown fields")
+}
+
+func TestLoadSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cli.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testSpecYAML), 0o600), "Test should write the spec")
+
+	spec, err := snek.LoadSpec(path)
+	require.NoError(t, err, "LoadSpec should not return an error")
+	assert.Equal(t, "app", spec.Use, "LoadSpec should parse the file")
+}
+
+func TestWithSpec(t *testing.T) {
+	t.Setenv("TEST_SPEC_PORT", "9090")
+
+	var port int
+	served := false
+	cmd, err := snek.NewCommand(snek.WithSpec(parseTestSpec(t, testSpecYAML), map[string]snek.Initializer{
+		"serve": snek.WithRunE(func(cmd *cobra.Command, args []string) error {
+			served = true
+			var err error
+			port, err = cmd.Flags().GetInt("port")
+			return err
+		}),
+		"version": snek.WithRun(func(*cobra.Command, []string) {}),
+	}))
+	require.NoError(t, err, "NewCommand should not return an error")
+
+	assert.Equal(t, "app", cmd.Use, "Use should be set")
+	assert.Equal(t, "The app", cmd.Short, "Short should be set")
+	assert.NotNil(t, cmd.PersistentFlags().ShorthandLookup("v"), "Persistent flags should be added")
+	require.Len(t, cmd.Commands(), 2, "Subcommands should be added")
+
+	serve, _, err := cmd.Find([]string{"server"})
+	require.NoError(t, err, "The subcommand should be found by its alias")
+	assert.Equal(t, "core", serve.GroupID, "GroupID should be set")
+
+	cmd.SetArgs([]string{"serve"})
+	assert.ErrorContains(t, cmd.Execute(), `required flag(s) "host" not set`,
+		"Required flags should be marked as required")
+
+	cmd.SetArgs([]string{"serve", "--host", "localhost"})
+	require.NoError(t, cmd.Execute(), "Execute should not return an error")
+	assert.True(t, served, "The handler should be called")
+	assert.Equal(t, 9090, port, "The environment variable should override the default")
+}
+
+func TestWithSpec_HandlerNotFound(t *testing.T) {
+	cmd, err := snek.NewCommand(snek.WithSpec(parseTestSpec(t, testSpecYAML), map[string]snek.Initializer{}))
+	assert.ErrorIs(t, err, snek.ErrSpecHandlerNotFound, "NewCommand should return an error for missing handlers")
+	assert.ErrorContains(t, err, `"serve"`, "The error should name the serve handler")
+	assert.ErrorContains(t, err, `"version"`, "The error should name the version handler")
+	assert.Nil(t, cmd, "NewCommand should not return a command")
+}
+
+func TestWithSpec_InvalidFlag(t *testing.T) {
+	tests := map[string]string{
+		"unknown type":    "use: app\nflags:\n  - name: port\n    type: port\n",
+		"invalid default": "use: app\nflags:\n  - name: port\n    type: int\n    default: eighty\n",
+	}
+
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd, err := snek.NewCommand(snek.WithSpec(parseTestSpec(t, spec), nil))
+			assert.ErrorIs(t, err, snek.ErrSpecInvalid, "NewCommand should return an error for invalid flags")
+			assert.Nil(t, cmd, "NewCommand should not return a command")
+		})
+	}
+}
+
+func TestRun_WithSpec(t *testing.T) {
+	called := false
+	err := snek.Run([]string{"version"}, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+		snek.WithSpec(parseTestSpec(t, testSpecYAML), map[string]snek.Initializer{
+			"serve": snek.WithRun(func(*cobra.Command, []string) {}),
+			"version": snek.WithRun(func(*cobra.Command, []string) {
+				called = true
+			}),
+		}),
+	)
+	require.NoError(t, err, "Run should not return an error")
+	assert.True(t, called, "Run should call the handler of the subcommand")
+}
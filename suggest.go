@@ -0,0 +1,162 @@
+package snek
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+)
+
+// installFlagSuggestions sets the flag error function of the root command, and
+// therefore of every command in the tree that does not set its own, to suggest
+// the closest known flags when an unknown flag is used. Suggestions are not
+// made for commands with DisableSuggestions set.
+func installFlagSuggestions(root *Command) {
+	next := root.FlagErrorFunc()
+	root.SetFlagErrorFunc(func(cmd *Command, err error) error {
+		var notExist *pflag.NotExistError
+		if cmd.DisableSuggestions || !errors.As(err, &notExist) || notExist.GetSpecifiedShortnames() != "" {
+			return next(cmd, err)
+		}
+
+		suggestions := suggestFlags(cmd, notExist.GetSpecifiedName())
+		if len(suggestions) == 0 {
+			return next(cmd, err)
+		}
+
+		var buf strings.Builder
+		buf.WriteString("\n\nDid you mean this?\n")
+		for _, suggestion := range suggestions {
+			fmt.Fprintf(&buf, "\t--%s\n", suggestion)
+		}
+		return next(cmd, fmt.Errorf("%w%s", err, buf.String()))
+	})
+}
+
+// suggestFlags returns the names of the flags of the command that are close to
+// the specified name, sorted by distance. A flag is close when its distance is
+// at most the suggestions minimum distance of the command, or when it starts
+// with the specified name.
+func suggestFlags(cmd *Command, name string) []string {
+	maxDistance := cmd.SuggestionsMinimumDistance
+	if maxDistance <= 0 {
+		maxDistance = 2
+	}
+
+	var candidates []string
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Hidden && flag.Deprecated == "" {
+			candidates = append(candidates, flag.Name)
+		}
+	})
+
+	return suggest(name, candidates, maxDistance)
+}
+
+// suggest returns the candidates that are within the maximum distance of the
+// name, or that start with the name, sorted by distance and then by name.
+func suggest(name string, candidates []string, maxDistance int) []string {
+	distances := map[string]int{}
+	var suggestions []string
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if distance <= maxDistance || strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(name)) {
+			distances[candidate] = distance
+			suggestions = append(suggestions, candidate)
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if distances[suggestions[i]] != distances[suggestions[j]] {
+			return distances[suggestions[i]] < distances[suggestions[j]]
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	return suggestions
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// transpositions of adjacent characters needed to turn a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// knownEnvironmentVariables returns the environment variables of every flag and
+// handler option in the command tree, along with the additional known
+// environment variables.
+func knownEnvironmentVariables(root *Command, additional []string) []string {
+	known := slices.Clone(additional)
+	collect := func(flag *pflag.Flag) {
+		known = append(known, flag.Annotations[FlagAnnotationEnvironmentVariable]...)
+	}
+
+	walkCommands(root, func(cmd *Command) {
+		cmd.Flags().VisitAll(collect)
+		cmd.PersistentFlags().VisitAll(collect)
+		if ext := lookupExtensions(cmd); ext != nil {
+			ext.mu.Lock()
+			known = append(known, ext.environmentVariables...)
+			ext.mu.Unlock()
+		}
+	})
+
+	slices.Sort(known)
+	return slices.Compact(known)
+}
+
+// checkEnvironmentVariables logs a warning for every environment variable that
+// starts with the prefix but is not one of the known environment variables,
+// suggesting the closest known environment variables. Nothing is checked when
+// the prefix is empty, as every environment variable would match it. The
+// warnings are logged with the logger.
+func checkEnvironmentVariables(logger *zerolog.Logger, prefix string, known []string) {
+	if prefix == "" {
+		return
+	}
+
+	var unknown []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if strings.HasPrefix(name, prefix) && !slices.Contains(known, name) {
+			unknown = append(unknown, name)
+		}
+	}
+
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		event := logger.Warn().Str("variable", name)
+		if suggestions := suggest(name, known, 2); len(suggestions) > 0 {
+			event = event.Strs("suggestions", suggestions)
+		}
+		event.Msg("Unknown environment variable.")
+	}
+}
@@ -0,0 +1,134 @@
+package snek_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ronelliott/snek"
+)
+
+func TestWithEnvironmentVariableCheck(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.False(t, cfg.EnvironmentVariableCheck, "EnvironmentVariableCheck should be false")
+	cfg = snek.NewConfig(snek.WithEnvironmentVariableCheck(true))
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.True(t, cfg.EnvironmentVariableCheck, "EnvironmentVariableCheck should be true")
+}
+
+func TestWithKnownEnvironmentVariables(t *testing.T) {
+	cfg := snek.NewConfig()
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	require.Empty(t, cfg.KnownEnvironmentVariables, "KnownEnvironmentVariables should be empty")
+	cfg = snek.NewConfig(
+		snek.WithKnownEnvironmentVariables("APP_TOKEN"),
+		snek.WithKnownEnvironmentVariables("APP_URL"),
+	)
+	require.NotNil(t, cfg, "NewConfig should return a Config")
+	assert.Equal(t, []string{"APP_TOKEN", "APP_URL"}, cfg.KnownEnvironmentVariables,
+		"KnownEnvironmentVariables should be appended to")
+}
+
+func TestRun_FlagSuggestions(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{name: "typo", args: []string{"--nmae"}, expected: []string{"--name"}},
+		{name: "prefix", args: []string{"--log"}, expected: []string{"--log-level", "--log-format"}},
+		{name: "inherited", args: []string{"sub", "--verbos"}, expected: []string{"--verbose"}},
+		{name: "none", args: []string{"--unrelated"}},
+		{name: "shorthand", args: []string{"-x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var name string
+			var verbose bool
+			sub, err := snek.NewCommand(snek.WithUse("sub"), snek.WithRun(func(*cobra.Command, []string) {}))
+			require.NoError(t, err, "NewCommand should not return an error")
+
+			err = snek.Run(tt.args, snek.NewConfig(snek.WithLogOutput(io.Discard)),
+				snek.WithUse("app"),
+				snek.WithFlag(snek.WithStringVar(&name, "name", "", "The name")),
+				snek.WithPersistentFlag(snek.WithBoolVar(&verbose, "verbose", false, "Verbose output")),
+				snek.WithSubCommand(sub),
+				snek.WithRun(func(*cobra.Command, []string) {}),
+				func(cmd *cobra.Command) error {
+					cmd.SetOut(io.Discard)
+					cmd.SetErr(io.Discard)
+					return nil
+				},
+			)
+			require.Error(t, err, "Run should return an error for an unknown flag")
+
+			var notExist *pflag.NotExistError
+			assert.ErrorAs(t, err, &notExist, "The error should wrap the unknown flag error")
+			if len(tt.expected) == 0 {
+				assert.NotContains(t, err.Error(), "Did you mean this?", "The error should not suggest any flags")
+				return
+			}
+
+			expected := "\n\nDid you mean this?\n\t" + strings.Join(tt.expected, "\n\t") + "\n"
+			assert.True(t, strings.HasSuffix(err.Error(), expected),
+				"The error should suggest the closest flags: %q", err.Error())
+		})
+	}
+}
+
+func TestRun_EnvironmentVariableCheck(t *testing.T) {
+	t.Setenv("APP_LOG_LEVLE", "debug")
+	t.Setenv("APP_NAME", "world")
+	t.Setenv("APP_TOKEN", "secret")
+	t.Setenv("APP_UNRELATED_SETTING", "value")
+
+	run := func(cfg *snek.Config) []map[string]any {
+		var buffer bytes.Buffer
+		snek.WithLogOutput(&buffer)(cfg)
+		var name string
+		err := snek.Run([]string{}, cfg,
+			snek.WithFlag(snek.WithStringVarE(&name, "name", "APP_NAME", "", "The name")),
+			snek.WithRun(func(*cobra.Command, []string) {}),
+		)
+		require.NoError(t, err, "Run should not return an error")
+
+		var warnings []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry), "Run should log in the JSON format")
+			if entry["level"] == "warn" {
+				warnings = append(warnings, entry)
+			}
+		}
+		return warnings
+	}
+
+	warnings := run(snek.NewConfig(
+		snek.WithDefaultLogFormat("json"),
+		snek.WithEnvironmentVariablePrefix("APP_"),
+		snek.WithEnvironmentVariableCheck(true),
+		snek.WithKnownEnvironmentVariables("APP_TOKEN"),
+	))
+	require.Len(t, warnings, 2, "Run should warn about each unknown environment variable")
+	assert.Equal(t, "Unknown environment variable.", warnings[0]["message"], "The warning should have a message")
+	assert.Equal(t, "APP_LOG_LEVLE", warnings[0]["variable"], "The warning should name the variable")
+	assert.Equal(t, []any{"APP_LOG_LEVEL"}, warnings[0]["suggestions"],
+		"The warning should suggest the closest known environment variable")
+	assert.Equal(t, "APP_UNRELATED_SETTING", warnings[1]["variable"], "The warning should name the variable")
+	assert.NotContains(t, warnings[1], "suggestions", "The warning should not suggest unrelated variables")
+
+	warnings = run(snek.NewConfig(
+		snek.WithDefaultLogFormat("json"),
+		snek.WithEnvironmentVariablePrefix("APP_"),
+	))
+	assert.Empty(t, warnings, "Run should not check environment variables unless enabled")
+}